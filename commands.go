@@ -0,0 +1,386 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg"
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg/config"
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg/secret"
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg/store"
+)
+
+const appName = "core-config-seed-go"
+
+// Options of the command line. The global ones apply to every command, the others to a single one.
+type options struct {
+	confDir string
+	profile string
+	ageKey  string
+	consul  bool
+
+	// rollback
+	to string
+	// watch
+	debounce int
+	// drift
+	drift driftOptions
+}
+
+// Register the global flags on fs. Their current values are the defaults, so that the flags given
+// before a command are kept when the flag set of the command is parsed.
+func (o *options) register(fs *flag.FlagSet) {
+	fs.BoolVar(&o.consul, "consul", o.consul, "Indicates the service should use consul, whatever the StoreType.")
+	fs.BoolVar(&o.consul, "c", o.consul, "Indicates the service should use consul, whatever the StoreType.")
+	fs.StringVar(&o.confDir, "confdir", o.confDir, "Specify the configuration directory of the tool, EDGEX_CONF_DIR or ./res by default.")
+	fs.StringVar(&o.profile, "profile", o.profile, "Specify profiles layered over the default, comma-separated.")
+	fs.StringVar(&o.profile, "p", o.profile, "Specify profiles layered over the default, comma-separated.")
+	fs.StringVar(&o.ageKey, "age-key", o.ageKey, "File of the age key decrypting encrypted values and files, "+ageKeyFileEnv+" by default.")
+}
+
+// What a command works with: the configuration of the tool and, unless the command reads only
+// the config files, the K/V store.
+type commandEnv struct {
+	ctx        context.Context
+	options    *options
+	coreConfig pkg.CoreConfig
+	store      store.ConfigStore
+}
+
+// A subcommand of the tool.
+type command struct {
+	name string
+	// args is the synopsis of the arguments, e.g. "<key> <value>".
+	args string
+	// minArgs and maxArgs bound the number of arguments.
+	minArgs, maxArgs int
+	// summary is the line of the command in the usage, help its full description.
+	summary, help string
+	// filesOnly commands read the config files without connecting to the K/V store.
+	filesOnly bool
+	// flags registers the flags of the command, if any.
+	flags func(fs *flag.FlagSet, o *options)
+	run   func(env *commandEnv, args []string) error
+}
+
+// The commands of the tool, in the order of the usage. The first is run when none is given.
+var commands = []*command{
+	{
+		name:    "seed",
+		summary: "Seed the K/V store from the config files",
+		help: `Read the config files under ConfigPath and ConfigPathV2 and write them to the K/V store,
+clearing the globalPrefix first with IsReset, or changing only the keys which differ with IsSync.
+The V2 services are read back and verified afterwards. This is the default command.`,
+		run: func(env *commandEnv, args []string) error {
+			return seedConfig(env.options.profile, env.coreConfig, env.store)
+		},
+	},
+	{
+		name:    "plan",
+		summary: "Print the changes a seed would make, without writing them",
+		help: `Compare the config files with the keys under the globalPrefix and list every key as
+added (+), changed (~), deleted (-) or left alone (=), followed by a summary.`,
+		run: func(env *commandEnv, args []string) error {
+			return planConfig(env.options.profile, env.coreConfig, env.store)
+		},
+	},
+	{
+		name:      "validate",
+		summary:   "Check the config files without connecting to the K/V store",
+		filesOnly: true,
+		help: `Read the config files the way a seed does: parse them, check the V2 services against their
+registered types, decrypt values and resolve references. Nothing is written.`,
+		run: func(env *commandEnv, args []string) error {
+			return validateConfig(env.options.profile, env.coreConfig)
+		},
+	},
+	{
+		name:    "export",
+		args:    "[<directory> | <file>.json]",
+		maxArgs: 1,
+		summary: "Write the keys of the K/V store back to config files",
+		help: `Write every key under the globalPrefix back to the config directories, or under another
+root directory, or to a single snapshot file when a .json file is given.`,
+		run: func(env *commandEnv, args []string) error {
+			return exportConfig(strings.Join(args, ""), env.options.profile, env.coreConfig, env.store)
+		},
+	},
+	{
+		name:    "diff",
+		args:    "<source> <source>",
+		minArgs: 2,
+		maxArgs: 2,
+		summary: "Print the differences between two configurations",
+		help: `Print the key-level differences between two sources for every service. A source is one of:
+  files                            the config files, read as a seed reads them
+  consul                           the keys under the globalPrefix in the K/V store
+  consul:<prefix>                  the keys under another prefix in the K/V store
+  consul://<host>:<port>/<prefix>  the keys under a prefix of another Consul agent
+  <directory>                      a config directory with one sub-directory per service
+  <file>                           a snapshot file, written by export`,
+		run: func(env *commandEnv, args []string) error {
+			return diffConfig(env.ctx, args[0], args[1], env.options.profile, env.coreConfig, env.store)
+		},
+	},
+	{
+		name:    "get",
+		args:    "<key>",
+		minArgs: 1,
+		maxArgs: 1,
+		summary: "Print the value of a key of the K/V store",
+		help:    `Print the value of a full key, e.g. config/EdgeX_Core_Data/Service/Port. The values of secret keys are redacted.`,
+		run: func(env *commandEnv, args []string) error {
+			return getKey(args[0], env.store)
+		},
+	},
+	{
+		name:    "put",
+		args:    "<key> <value>",
+		minArgs: 2,
+		maxArgs: 2,
+		summary: "Write the value of a key of the K/V store",
+		help: `Write the value of a full key, e.g. config/EdgeX_Core_Data/Service/Port. The change is lost
+at the next seed unless the config files are changed as well.`,
+		run: func(env *commandEnv, args []string) error {
+			return putKey(args[0], args[1], env.store)
+		},
+	},
+	{
+		name:    "watch",
+		summary: "Sync the K/V store whenever the config files change",
+		help: `Sync the K/V store with the config files, then watch ConfigPath and ConfigPathV2 and sync
+again the service directories whose files change, until the tool is stopped by SIGTERM or Ctrl-C.
+Bursts of changes, such as an editor saving a file, are applied once they settle down.
+Only the keys which differ are written, and every change is logged.`,
+		flags: func(fs *flag.FlagSet, o *options) {
+			fs.IntVar(&o.debounce, "debounce", 500, "Milliseconds without changes before the changed services are synced.")
+		},
+		run: func(env *commandEnv, args []string) error {
+			debounce := time.Duration(env.options.debounce) * time.Millisecond
+			return watchConfig(env.ctx, env.options.profile, env.coreConfig, env.store, debounce)
+		},
+	},
+	{
+		name:    "drift",
+		summary: "Report the keys of the K/V store edited by hand",
+		help: `Follow the keys under the globalPrefix with Consul blocking queries and compare them with the
+config files every time they change. Keys changed, added or deleted by hand are reported to stdout,
+to a JSON file with -report and to an HTTP webhook with -webhook, the values of secret keys being
+redacted. With -fix consul the values of the files are written back to the K/V store; with -fix files
+the drifted services are exported to their files, as the export command does.`,
+		flags: func(fs *flag.FlagSet, o *options) {
+			fs.StringVar(&o.drift.Report, "report", "", "File the last drift report is written to as JSON.")
+			fs.StringVar(&o.drift.Webhook, "webhook", "", "URL every drift report is posted to as JSON.")
+			fs.StringVar(&o.drift.Fix, "fix", "", "Fix a drift: consul reverts the K/V store, files writes the stored values to the files.")
+			fs.BoolVar(&o.drift.Once, "once", false, "Compare once instead of following the changes.")
+		},
+		run: func(env *commandEnv, args []string) error {
+			return driftConfig(env.ctx, env.options.profile, env.coreConfig, env.store, env.options.drift)
+		},
+	},
+	{
+		name:    "rollback",
+		summary: "Restore a snapshot saved before a seed",
+		help:    `Restore a snapshot saved before a seed, deleting the keys which are not in it. Without -to the saved snapshots are listed.`,
+		flags: func(fs *flag.FlagSet, o *options) {
+			fs.StringVar(&o.to, "to", "", "Id of the snapshot to restore.")
+		},
+		run: func(env *commandEnv, args []string) error {
+			return rollbackConfig(env.options.to, env.coreConfig, env.store)
+		},
+	},
+}
+
+func lookupCommand(name string) (*command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return nil, false
+}
+
+// Parse the command line and run its command: the global flags, the name of the command, then
+// its flags, which may include the global ones again, and its arguments.
+func run(args []string) error {
+	o := &options{}
+	fs := flag.NewFlagSet(appName, flag.ContinueOnError)
+	o.register(fs)
+	fs.Usage = func() { printUsage(fs.Output(), fs) }
+	if err := fs.Parse(args); err == flag.ErrHelp {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	name, args := commands[0].name, fs.Args()
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" {
+		return printHelp(os.Stdout, fs, o, args)
+	}
+	cmd, ok := lookupCommand(name)
+	if !ok {
+		printUsage(fs.Output(), fs)
+		return fmt.Errorf("unknown command %q", name)
+	}
+
+	cmdFlags := newCommandFlags(cmd, o)
+	if err := cmdFlags.Parse(args); err == flag.ErrHelp {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	args = cmdFlags.Args()
+	if len(args) < cmd.minArgs || len(args) > cmd.maxArgs {
+		return fmt.Errorf("usage: %s %s %s", appName, cmd.name, cmd.args)
+	}
+
+	ctx, cancel := newSignalContext()
+	defer cancel()
+
+	env, err := newCommandEnv(ctx, cmd, o)
+	if err != nil {
+		return err
+	}
+	return cmd.run(env, args)
+}
+
+// The flag set of a command: the global flags and its own.
+func newCommandFlags(cmd *command, o *options) *flag.FlagSet {
+	fs := flag.NewFlagSet(appName+" "+cmd.name, flag.ContinueOnError)
+	o.register(fs)
+	if cmd.flags != nil {
+		cmd.flags(fs, o)
+	}
+	fs.Usage = func() { printCommandHelp(fs.Output(), cmd, fs) }
+	return fs
+}
+
+// Load the configuration of the tool and connect to the K/V store for a command.
+func newCommandEnv(ctx context.Context, cmd *command, o *options) (*commandEnv, error) {
+	// Configuration data for the config-seed service.
+	coreConfig := &pkg.CoreConfig{}
+
+	// Load based on configuration need (docker or go)
+	config.ConfDir = o.confDir
+	err := config.LoadFromFile("", coreConfig)
+	if err != nil {
+		return nil, withExitCode(exitConfigLoad, err)
+	}
+	if o.consul {
+		coreConfig.StoreType = store.Consul
+	}
+
+	secrets, err = secret.NewMatcher(coreConfig.SecretPatterns)
+	if err != nil {
+		return nil, withExitCode(exitConfigLoad, err)
+	}
+
+	decrypter, err = newDecrypter(o.ageKey)
+	if err != nil {
+		return nil, withExitCode(exitConfigLoad, err)
+	}
+
+	env := &commandEnv{ctx: ctx, options: o, coreConfig: *coreConfig}
+	if !cmd.filesOnly {
+		env.store, err = newConfigStore(ctx, *coreConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return env, nil
+}
+
+// Print the usage of the tool, listing its commands and global flags.
+func printUsage(w io.Writer, fs *flag.FlagSet) {
+	fmt.Fprintf(w, "Usage: %s [flags] <command> [arguments]\n\nCommands:\n", appName)
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w, "\nFlags:")
+	fs.SetOutput(w)
+	fs.PrintDefaults()
+	fmt.Fprintf(w, "\nRun '%s help <command>' for the help of a command.\n", appName)
+}
+
+// Print the help of a command, its flags including the global ones.
+func printCommandHelp(w io.Writer, cmd *command, fs *flag.FlagSet) {
+	fmt.Fprintf(w, "Usage: %s [flags] %s", appName, cmd.name)
+	if cmd.args != "" {
+		fmt.Fprint(w, " "+cmd.args)
+	}
+	fmt.Fprintf(w, "\n\n%s\n\nFlags:\n", cmd.help)
+	fs.SetOutput(w)
+	fs.PrintDefaults()
+}
+
+// The help command: the usage of the tool, or the help of the command given.
+func printHelp(w io.Writer, fs *flag.FlagSet, o *options, args []string) error {
+	if len(args) == 0 {
+		printUsage(w, fs)
+		return nil
+	}
+	cmd, ok := lookupCommand(args[0])
+	if !ok {
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+	printCommandHelp(w, cmd, newCommandFlags(cmd, o))
+	return nil
+}
+
+// Read the config files the way a seed does, which parses, validates, decrypts and interpolates
+// them, without writing anything.
+func validateConfig(profile string, coreConfig pkg.CoreConfig) error {
+	services, err := readServices(profile, coreConfig)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Validation passed: %d services.\n", len(services))
+	return nil
+}
+
+// Print the value of a key of the K/V store, redacted when it is secret.
+func getKey(key string, s store.ConfigStore) error {
+	value, ok, err := s.Get(key)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("key %s not found", key)
+	}
+	fmt.Println(secrets.Redact(key, string(value)))
+	return nil
+}
+
+// Write the value of a key of the K/V store.
+func putKey(key string, value string, s store.ConfigStore) error {
+	if key == "" {
+		return errors.New("the key must not be empty")
+	}
+	if err := s.Put(key, []byte(value)); err != nil {
+		return withExitCode(exitPartialWrite, err)
+	}
+	fmt.Println("put key", key, "with value", secrets.Redact(key, value))
+	return nil
+}
@@ -0,0 +1,83 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg/secret"
+)
+
+func TestRunCommandLine(t *testing.T) {
+	defer func(m *secret.Matcher) { secrets = m }(secrets)
+
+	// Global flags are accepted before and after the command.
+	if err := run([]string{"-p", "docker", "validate"}); err != nil {
+		t.Errorf("validate: %v", err)
+	}
+	if err := run([]string{"validate", "-p", "docker"}); err != nil {
+		t.Errorf("validate with trailing flags: %v", err)
+	}
+	// -c and -consul are kept for the invocations written before the commands.
+	if err := run([]string{"-c", "validate", "-consul"}); err != nil {
+		t.Errorf("validate with -c: %v", err)
+	}
+	if err := run([]string{"help", "put"}); err != nil {
+		t.Errorf("help: %v", err)
+	}
+
+	for _, args := range [][]string{{"bogus"}, {"get"}, {"put", "key"}, {"diff", "files"}, {"validate", "-bogus"}} {
+		err := run(args)
+		if err == nil {
+			t.Errorf("%v: expected an error", args)
+		} else if code := exitCode(err); code != exitFailure {
+			t.Errorf("%v: expected exit code %d, got %d", args, exitFailure, code)
+		}
+	}
+}
+
+func TestPrintCommandHelp(t *testing.T) {
+	cmd, ok := lookupCommand("watch")
+	if !ok {
+		t.Fatal("watch is not a command")
+	}
+	var out bytes.Buffer
+	printCommandHelp(&out, cmd, newCommandFlags(cmd, &options{}))
+	for _, expected := range []string{"Usage: core-config-seed-go [flags] watch", "-debounce", "-profile"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected %q in\n%s", expected, out.String())
+		}
+	}
+}
+
+func TestGetAndPutKey(t *testing.T) {
+	defer func(m *secret.Matcher) { secrets = m }(secrets)
+	secrets, _ = secret.NewMatcher([]string{"(?i)password"})
+	s := newMemoryStore(nil)
+
+	if err := putKey("config/Svc/Port", "48080", s); err != nil {
+		t.Fatal(err)
+	}
+	if s.data["config/Svc/Port"] != "48080" {
+		t.Errorf("unexpected store %v", s.data)
+	}
+	if err := getKey("config/Svc/Missing", s); err == nil {
+		t.Error("expected an error for a missing key")
+	}
+	if err := putKey("config/Svc/Port", "1", failingStore{s}); exitCode(err) != exitPartialWrite {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,164 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg"
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+// Build the configuration of the Consul API client from the address, ACL token and TLS settings.
+func newConsulConfig(coreConfig pkg.CoreConfig) (*consulapi.Config, error) {
+	consulConfig := consulDefaultConfig()
+	consulConfig.Scheme = coreConfig.ConsulProtocol
+	consulConfig.Address = coreConfig.ConsulHost + ":" + strconv.Itoa(coreConfig.ConsulPort)
+
+	// Without a token of its own the seed keeps the one of CONSUL_HTTP_TOKEN, if any.
+	switch {
+	case coreConfig.ConsulToken != "":
+		consulConfig.Token = coreConfig.ConsulToken
+	case coreConfig.ConsulTokenFile != "":
+		b, err := ioutil.ReadFile(coreConfig.ConsulTokenFile)
+		if err != nil {
+			return nil, err
+		}
+		consulConfig.Token = strings.TrimSpace(string(b))
+	}
+
+	consulConfig.TLSConfig = consulapi.TLSConfig{
+		CAFile:             coreConfig.ConsulCACert,
+		CertFile:           coreConfig.ConsulClientCert,
+		KeyFile:            coreConfig.ConsulClientKey,
+		InsecureSkipVerify: coreConfig.ConsulInsecureSkipVerify,
+	}
+	return consulConfig, nil
+}
+
+// An HTTP client for the requests made outside the API client, with the same TLS settings.
+func newConsulHTTPClient(consulConfig *consulapi.Config) (*http.Client, error) {
+	tlsConfig, err := consulapi.SetupTLSConfig(&consulConfig.TLSConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: tlsConfig}}, nil
+}
+
+// GET a path of the Consul agent with the ACL token, returning the body of a 2xx answer.
+func getConsulPath(ctx context.Context, consulConfig *consulapi.Config, httpClient *http.Client, path string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, consulConfig.Scheme+"://"+consulConfig.Address+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if consulConfig.Token != "" {
+		req.Header.Set("X-Consul-Token", consulConfig.Token)
+	}
+
+	resp, err := httpDo(httpClient, req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("%s answered %s", path, resp.Status)
+	}
+	return body, nil
+}
+
+// Check once whether the agent answers and its cluster has elected a leader. An agent can answer
+// before that, while the K/V store is not usable yet.
+func checkConsulReady(ctx context.Context, consulConfig *consulapi.Config, httpClient *http.Client) error {
+	if _, err := getConsulPath(ctx, consulConfig, httpClient, consulStatusPath); err != nil {
+		return err
+	}
+
+	body, err := getConsulPath(ctx, consulConfig, httpClient, consulLeaderPath)
+	if err != nil {
+		return err
+	}
+	var leader string
+	if err := json.Unmarshal(body, &leader); err != nil {
+		return fmt.Errorf("%s answered %q: %v", consulLeaderPath, body, err)
+	}
+	if leader == "" {
+		return errors.New("the Consul cluster has no leader yet")
+	}
+	return nil
+}
+
+// The shortest wait doubled by consulBackoff, taken when FailWaitTime is not set so that the
+// retries never spin.
+const minConsulBackoff = time.Second
+
+// The wait after a failed attempt, counted from 0: FailWaitTime doubled at every attempt, up to
+// MaxWaitTime. Jitter picks a duration between half and all of it, so that seeders started
+// together do not retry together.
+func consulBackoff(coreConfig pkg.CoreConfig, attempt int) time.Duration {
+	d := time.Duration(coreConfig.FailWaitTime) * time.Second
+	if d < minConsulBackoff {
+		d = minConsulBackoff
+	}
+	max := time.Duration(coreConfig.MaxWaitTime) * time.Second
+	for i := 0; i < attempt && (max <= 0 || d < max); i++ {
+		d *= 2
+	}
+	if max > 0 && d > max {
+		d = max
+	}
+	return d/2 + time.Duration(randFloat()*float64(d/2))
+}
+
+// Wait until Consul is ready, retrying with consulBackoff. It gives up after FailLimit attempts,
+// after ReadyTimeout seconds or when ctx is done, whichever comes first. Zero means no limit.
+func waitForConsul(ctx context.Context, coreConfig pkg.CoreConfig, consulConfig *consulapi.Config, httpClient *http.Client) error {
+	if coreConfig.ReadyTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(coreConfig.ReadyTimeout)*time.Second)
+		defer cancel()
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := checkConsulReady(ctx, consulConfig, httpClient)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("Cannot get connection to Consul: %v, last error: %v", ctx.Err(), err)
+		}
+		fmt.Println("Consul is not ready:", err.Error())
+		if coreConfig.FailLimit > 0 && attempt+1 >= coreConfig.FailLimit {
+			return fmt.Errorf("Cannot get connection to Consul after %d attempts: %v", attempt+1, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("Cannot get connection to Consul: %v, last error: %v", ctx.Err(), err)
+		case <-time.After(consulBackoff(coreConfig, attempt)):
+		}
+	}
+}
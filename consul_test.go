@@ -0,0 +1,202 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package main
+
+import (
+	"context"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg"
+)
+
+func TestGetConsulClientWithTokenAndTLS(t *testing.T) {
+	agent := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Consul-Token") != "s3cr3t" {
+			http.Error(w, "ACL not found", http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case consulStatusPath:
+			w.Write([]byte("{}"))
+		case consulLeaderPath:
+			w.Write([]byte(`"10.0.0.1:8300"`))
+		case "/v1/kv/config/svc/Host":
+			w.Header().Set("X-Consul-Index", "1")
+			w.Write([]byte(`[{"Key":"config/svc/Host","Value":"bG9jYWxob3N0"}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer agent.Close()
+
+	caCert, cleanup := writeTestFile(t, "ca.pem",
+		string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: agent.Certificate().Raw})))
+	defer cleanup()
+	tokenFile := filepath.Join(filepath.Dir(caCert), "token")
+	if err := ioutil.WriteFile(tokenFile, []byte("s3cr3t\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := url.Parse(agent.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, _ := strconv.Atoi(u.Port())
+	coreConfig := pkg.CoreConfig{
+		ConsulProtocol:  "https",
+		ConsulHost:      u.Hostname(),
+		ConsulPort:      port,
+		ConsulTokenFile: tokenFile,
+		ConsulCACert:    caCert,
+		FailLimit:       1,
+	}
+
+	client, err := getConsulClient(context.Background(), coreConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pair, _, err := client.KV().Get("config/svc/Host", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pair == nil || string(pair.Value) != "localhost" {
+		t.Errorf("unexpected pair %+v", pair)
+	}
+
+	// Without the CA the certificate of the agent is not trusted.
+	coreConfig.ConsulCACert = ""
+	if _, err := getConsulClient(context.Background(), coreConfig); err == nil {
+		t.Error("expected an error without the CA certificate")
+	}
+	coreConfig.ConsulInsecureSkipVerify = true
+	if _, err := getConsulClient(context.Background(), coreConfig); err != nil {
+		t.Errorf("unexpected error skipping verification: %v", err)
+	}
+}
+
+// A plain HTTP agent answering the status paths from a list of canned answers, one per attempt.
+func newStatusAgent(answers []func(w http.ResponseWriter, r *http.Request)) (*httptest.Server, pkg.CoreConfig, *int) {
+	attempts := 0
+	agent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		answer := answers[len(answers)-1]
+		if attempts < len(answers) {
+			answer = answers[attempts]
+		}
+		if r.URL.Path == consulLeaderPath || answer == nil {
+			attempts++
+		}
+		if answer == nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		answer(w, r)
+	}))
+	u, _ := url.Parse(agent.URL)
+	port, _ := strconv.Atoi(u.Port())
+	return agent, pkg.CoreConfig{ConsulProtocol: "http", ConsulHost: u.Hostname(), ConsulPort: port}, &attempts
+}
+
+func leaderAnswer(leader string) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == consulLeaderPath {
+			w.Write([]byte(strconv.Quote(leader)))
+			return
+		}
+		w.Write([]byte("{}"))
+	}
+}
+
+func waitForAgent(ctx context.Context, coreConfig pkg.CoreConfig) error {
+	consulConfig, err := newConsulConfig(coreConfig)
+	if err != nil {
+		return err
+	}
+	return waitForConsul(ctx, coreConfig, consulConfig, http.DefaultClient)
+}
+
+func TestWaitForConsulRetriesUntilLeader(t *testing.T) {
+	agent, coreConfig, attempts := newStatusAgent([]func(w http.ResponseWriter, r *http.Request){
+		nil, leaderAnswer(""), leaderAnswer("10.0.0.1:8300"),
+	})
+	defer agent.Close()
+
+	if err := waitForAgent(context.Background(), coreConfig); err != nil {
+		t.Fatal(err)
+	}
+	if *attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", *attempts)
+	}
+}
+
+func TestWaitForConsulStopsAtFailLimit(t *testing.T) {
+	agent, coreConfig, attempts := newStatusAgent([]func(w http.ResponseWriter, r *http.Request){nil})
+	defer agent.Close()
+	coreConfig.FailLimit = 3
+
+	err := waitForAgent(context.Background(), coreConfig)
+	if err == nil || !strings.Contains(err.Error(), "after 3 attempts") || !strings.Contains(err.Error(), "500") {
+		t.Errorf("unexpected error %v", err)
+	}
+	if *attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", *attempts)
+	}
+}
+
+func TestWaitForConsulStopsWhenCancelled(t *testing.T) {
+	agent, coreConfig, _ := newStatusAgent([]func(w http.ResponseWriter, r *http.Request){nil})
+	defer agent.Close()
+	coreConfig.FailWaitTime = 60
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	err := waitForAgent(ctx, coreConfig)
+	if err == nil || !strings.Contains(err.Error(), "context canceled") {
+		t.Errorf("unexpected error %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("cancelling did not stop the wait")
+	}
+}
+
+func TestConsulBackoff(t *testing.T) {
+	defer func(f func() float64) { randFloat = f }(randFloat)
+	coreConfig := pkg.CoreConfig{FailWaitTime: 1, MaxWaitTime: 10}
+
+	randFloat = func() float64 { return 1 }
+	for attempt, want := range []int{1, 2, 4, 8, 10, 10} {
+		if d := consulBackoff(coreConfig, attempt); d != time.Duration(want)*time.Second {
+			t.Errorf("attempt %d: expected %ds, got %v", attempt, want, d)
+		}
+	}
+	randFloat = func() float64 { return 0 }
+	if d := consulBackoff(coreConfig, 2); d != 2*time.Second {
+		t.Errorf("expected the jitter to keep half of 4s, got %v", d)
+	}
+
+	// Without a FailWaitTime the retries still wait.
+	coreConfig.FailWaitTime = 0
+	if d := consulBackoff(coreConfig, 0); d != minConsulBackoff/2 {
+		t.Errorf("expected half of the minimum wait, got %v", d)
+	}
+}
@@ -0,0 +1,175 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg"
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg/store"
+)
+
+// Print the key-level differences between two sources for every service.
+// A source is one of:
+//   files                       the ConfigPath and ConfigPathV2 directories, as a seed reads them
+//   consul                      the keys under the global prefix in the K/V store
+//   consul:<prefix>             the keys under another prefix in the K/V store
+//   consul://<host>:<port>/<prefix>  the keys under a prefix of another Consul agent
+//   <directory>                 a config directory with one sub-directory per service
+//   <file>                      a snapshot file written by export
+func diffConfig(ctx context.Context, from string, to string, profile string, coreConfig pkg.CoreConfig, s store.ConfigStore) error {
+	// The diff alone goes to stdout.
+	defer func(w io.Writer) { logOutput = w }(logOutput)
+	logOutput = os.Stderr
+
+	a, err := readSource(ctx, from, profile, coreConfig, s)
+	if err != nil {
+		return err
+	}
+	b, err := readSource(ctx, to, profile, coreConfig, s)
+	if err != nil {
+		return err
+	}
+
+	printDiff(os.Stdout, from, to, a, b)
+	return nil
+}
+
+// Read a source to keys relative to its prefix, "<service>/<key>".
+func readSource(ctx context.Context, spec string, profile string, coreConfig pkg.CoreConfig, s store.ConfigStore) (pkg.ConfigProperties, error) {
+	switch {
+	case spec == "files":
+		desired, err := desiredConfig(profile, coreConfig)
+		if err != nil {
+			return nil, err
+		}
+		return relativeKeys(coreConfig.GlobalPrefix, desired), nil
+
+	case spec == "consul":
+		stored, err := storedConfig(coreConfig, s)
+		if err != nil {
+			return nil, err
+		}
+		return relativeKeys(coreConfig.GlobalPrefix, stored), nil
+
+	case strings.HasPrefix(spec, "consul://"):
+		u, err := url.Parse(spec)
+		if err != nil {
+			return nil, err
+		}
+		remote := coreConfig
+		remote.ConsulHost = u.Hostname()
+		if port := u.Port(); port != "" {
+			if remote.ConsulPort, err = strconv.Atoi(port); err != nil {
+				return nil, err
+			}
+		}
+		if prefix := strings.Trim(u.Path, "/"); prefix != "" {
+			remote.GlobalPrefix = prefix
+		}
+		consulClient, err := getConsulClient(ctx, remote)
+		if err != nil {
+			return nil, err
+		}
+		stored, err := storedConfig(remote, store.NewConsulStore(consulClient.KV()))
+		if err != nil {
+			return nil, err
+		}
+		return relativeKeys(remote.GlobalPrefix, stored), nil
+
+	case strings.HasPrefix(spec, "consul:"):
+		other := coreConfig
+		other.GlobalPrefix = strings.Trim(strings.TrimPrefix(spec, "consul:"), "/")
+		stored, err := storedConfig(other, s)
+		if err != nil {
+			return nil, err
+		}
+		return relativeKeys(other.GlobalPrefix, stored), nil
+	}
+
+	info, err := os.Stat(spec)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return readDirSource(spec, profile, coreConfig)
+	}
+	snap, err := readSnapshotFile(spec)
+	if err != nil {
+		return nil, err
+	}
+	return snap.Pairs, nil
+}
+
+// Read a config directory with the readers of a seed, as both its ConfigPath and ConfigPathV2:
+// the configuration*.toml files of a service are layered for the profile, the other files are read
+// as V1 configuration, and the values are overridden, decrypted and interpolated alike.
+func readDirSource(dir string, profile string, coreConfig pkg.CoreConfig) (pkg.ConfigProperties, error) {
+	other := coreConfig
+	other.ConfigPath, other.ConfigPathV2 = dir, dir
+	services, err := readServices(profile, other)
+	if err != nil {
+		return nil, err
+	}
+	return relativeKeys(coreConfig.GlobalPrefix, flattenServices(services)), nil
+}
+
+// Print the differences of every service, sorted by service. Keys only in b are added (+),
+// keys only in a are deleted (-) and keys with another value are changed (~).
+func printDiff(w io.Writer, from string, to string, a, b pkg.ConfigProperties) {
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", from, to)
+
+	servicesA, servicesB := groupByService("", a), groupByService("", b)
+	names := []string{}
+	for name := range servicesA {
+		names = append(names, name)
+	}
+	for name := range servicesB {
+		if _, ok := servicesA[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	differing := 0
+	for _, name := range names {
+		plan := buildPlan(servicesB[name], servicesA[name], true)
+		changes := 0
+		for _, e := range plan {
+			if e.Action != planKeep {
+				changes++
+			}
+		}
+		if changes == 0 {
+			fmt.Fprintf(w, "\n%s: identical (%d keys)\n", name, len(plan))
+			continue
+		}
+
+		differing++
+		fmt.Fprintf(w, "\n%s: %d of %d keys differ\n", name, changes, len(plan))
+		for _, e := range plan {
+			if e.Action != planKeep {
+				printPlanEntry(w, e)
+			}
+		}
+	}
+	fmt.Fprintf(w, "\n%d of %d services differ.\n", differing, len(names))
+}
@@ -0,0 +1,139 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg"
+)
+
+func TestPrintDiff(t *testing.T) {
+	a := pkg.ConfigProperties{
+		"EdgeX_Core_Data/Service/Port": "48080",
+		"EdgeX_Core_Data/Service/Host": "localhost",
+		"device-virtual/server.port":   "49990",
+	}
+	b := pkg.ConfigProperties{
+		"EdgeX_Core_Data/Service/Port":  "48081",
+		"EdgeX_Core_Data/Database/Host": "mongo",
+		"device-virtual/server.port":    "49990",
+	}
+
+	var buf bytes.Buffer
+	printDiff(&buf, "files", "consul", a, b)
+
+	out := buf.String()
+	for _, line := range []string{
+		"EdgeX_Core_Data: 3 of 3 keys differ",
+		`  + Database/Host = "mongo"`,
+		`  - Service/Host = "localhost"`,
+		`  ~ Service/Port = "48080" -> "48081"`,
+		"device-virtual: identical (1 keys)",
+		"1 of 2 services differ.",
+	} {
+		if !strings.Contains(out, line) {
+			t.Errorf("missing %q in:\n%s", line, out)
+		}
+	}
+}
+
+func TestReadSourceSnapshot(t *testing.T) {
+	path, cleanup := writeTestFile(t, "snapshot.json", "")
+	defer cleanup()
+
+	stored := pkg.ConfigProperties{"config/EdgeX_Core_Data/Service/Port": "48080"}
+	if err := writeSnapshotFile(path, newSnapshot("config", stored)); err != nil {
+		t.Fatal(err)
+	}
+
+	props, err := readSource(context.Background(), path, "", pkg.CoreConfig{GlobalPrefix: "config"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if props["EdgeX_Core_Data/Service/Port"] != "48080" || len(props) != 1 {
+		t.Errorf("bad snapshot keys: %#v", props)
+	}
+}
+
+func TestReadDirSource(t *testing.T) {
+	coreConfig := pkg.CoreConfig{
+		AcceptablePropertyExtensions: []string{".toml"},
+		TomlExtensions:               []string{".toml"},
+	}
+
+	props, err := readDirSource(filepath.Join("pkg", "v2", "toml"), "", coreConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if props["EdgeX_Core_Data/Service/Port"] != "48082" {
+		t.Errorf("bad Service/Port: %q", props["EdgeX_Core_Data/Service/Port"])
+	}
+	// Keys of configuration-docker.toml are not read without the docker profile.
+	if _, ok := props["EdgeX_Core_Data/ServicePort"]; ok {
+		t.Error("read the configuration file of another profile")
+	}
+}
+
+// A directory is read like the config paths of a seed, its logging kept out of the diff.
+func TestReadDirSourcePipeline(t *testing.T) {
+	root, err := ioutil.TempDir("", "diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	writeCoreDataConfig(t, root, "", "")
+	if err := os.MkdirAll(filepath.Join(root, "device-virtual"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, "device-virtual", "application.properties"),
+		[]byte("server.port=49990\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(f func() []string) { environ = f }(environ)
+	environ = func() []string { return []string{"EDGEX_SEED__EdgeX_Core_Data__Service__Host=edgex-core-data"} }
+	defer func(w io.Writer) { logOutput = w }(logOutput)
+	var log bytes.Buffer
+	logOutput = &log
+
+	coreConfig := pkg.CoreConfig{
+		GlobalPrefix:                 "config",
+		AcceptablePropertyExtensions: []string{".toml", ".properties"},
+		TomlExtensions:               []string{".toml"},
+	}
+	props, err := readDirSource(root, "", coreConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if props["EdgeX_Core_Data/Service/Host"] != "edgex-core-data" {
+		t.Errorf("the environment override was not applied: %q", props["EdgeX_Core_Data/Service/Host"])
+	}
+	if props["device-virtual/server.port"] != "49990" {
+		t.Errorf("bad server.port: %q", props["device-virtual/server.port"])
+	}
+	if _, ok := props["EdgeX_Core_Data/configuration.toml"]; ok || len(props) == 0 {
+		t.Errorf("unexpected keys %v", props)
+	}
+	if !strings.Contains(log.String(), "found config file: configuration.toml in context EdgeX_Core_Data/") {
+		t.Errorf("the files found were not logged:\n%s", log.String())
+	}
+}
@@ -0,0 +1,335 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg"
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg/store"
+)
+
+// Ways of fixing a drift, as used by the -fix flag of the drift command.
+const (
+	fixFiles  = "files"
+	fixConsul = "consul"
+)
+
+// Where a drift is reported and how it is fixed.
+type driftOptions struct {
+	// Report is a file the last report is written to as JSON.
+	Report string
+	// Webhook is a URL every report is posted to as JSON.
+	Webhook string
+	// Fix is empty, fixFiles or fixConsul.
+	Fix string
+	// Once stops after the first comparison instead of waiting for changes.
+	Once bool
+}
+
+// Keys of the store which differ from the config files, the values of secret keys being redacted.
+type driftReport struct {
+	Time    time.Time    `json:"time"`
+	Prefix  string       `json:"prefix"`
+	Drifted []driftedKey `json:"drifted"`
+}
+
+type driftedKey struct {
+	Key string `json:"key"`
+	// Change is "changed", "added" to the store or "deleted" from the store.
+	Change string `json:"change"`
+	File   string `json:"file,omitempty"`
+	Stored string `json:"stored,omitempty"`
+}
+
+// Compare the keys stored under the global prefix with the config files every time they change,
+// following them with blocking queries until ctx is done. A drift is reported when it differs from
+// the last one, then fixed when asked: fixConsul writes the values of the files back to the store,
+// fixFiles writes the drifted keys to the files which set them.
+func driftConfig(ctx context.Context, profile string, coreConfig pkg.CoreConfig, s store.ConfigStore, d driftOptions) error {
+	watcher, ok := s.(store.Watcher)
+	if !ok {
+		return withExitCode(exitConfigLoad, fmt.Errorf("StoreType %q does not support blocking queries, use consul to monitor a drift", coreConfig.StoreType))
+	}
+	if d.Fix != "" && d.Fix != fixFiles && d.Fix != fixConsul {
+		return fmt.Errorf("-fix must be %s or %s, not %q", fixFiles, fixConsul, d.Fix)
+	}
+
+	var index uint64
+	var last *driftReport
+	for attempt := 0; ; {
+		values, next, err := watcher.WatchPrefix(ctx, coreConfig.GlobalPrefix+"/", index)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			if d.Once {
+				return withExitCode(exitStoreUnreachable, err)
+			}
+			fmt.Println("drift:", err.Error())
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(consulBackoff(coreConfig, attempt)):
+			}
+			attempt++
+			continue
+		}
+		attempt = 0
+		// Consul resets its index at times, start over then.
+		if next < index {
+			next = 0
+		}
+		index = next
+
+		err = checkDrift(ctx, values, profile, coreConfig, s, d, &last)
+		if d.Once {
+			return err
+		}
+		if err != nil {
+			fmt.Println("drift:", err.Error())
+		}
+	}
+}
+
+// Compare the stored values with the config files once, reporting and fixing the drift found.
+// A report becomes the last one only once delivered, so that a failed delivery is sent again on the
+// next check, and a failed delivery does not keep the drift from being fixed.
+func checkDrift(ctx context.Context, values map[string][]byte, profile string, coreConfig pkg.CoreConfig, s store.ConfigStore, d driftOptions, last **driftReport) error {
+	stored := pkg.ConfigProperties{}
+	for k, v := range values {
+		// Skip the folder keys created by the Consul UI.
+		if !strings.HasSuffix(k, "/") {
+			stored[k] = string(v)
+		}
+	}
+	services, err := readServices(profile, coreConfig)
+	if err != nil {
+		return err
+	}
+	desired := flattenServices(services)
+
+	report := buildDriftReport(coreConfig.GlobalPrefix, desired, stored)
+	var sendErr error
+	if *last == nil || !reflect.DeepEqual((*last).Drifted, report.Drifted) {
+		if sendErr = sendDriftReport(ctx, report, d); sendErr == nil {
+			*last = report
+		}
+	}
+	if len(report.Drifted) == 0 {
+		return sendErr
+	}
+
+	if err := fixDrift(report, services, desired, stored, coreConfig, s, d); err != nil {
+		if sendErr != nil {
+			fmt.Println("drift:", sendErr.Error())
+		}
+		return err
+	}
+	return sendErr
+}
+
+// Fix a drift as d asks, from the config files or from the store. A snapshot is saved before the
+// store is written when enabled.
+func fixDrift(report *driftReport, services []*serviceConfig, desired, stored pkg.ConfigProperties, coreConfig pkg.CoreConfig, s store.ConfigStore, d driftOptions) error {
+	switch d.Fix {
+	case fixConsul:
+		plan := []planEntry{}
+		for _, e := range buildPlan(desired, stored, isPruning(coreConfig)) {
+			if e.Action != planKeep {
+				plan = append(plan, e)
+			}
+		}
+		if err := saveSnapshotIfEnabled(coreConfig, s); err != nil {
+			return err
+		}
+		return withExitCode(exitPartialWrite, applyChanges(plan, coreConfig, stored, s))
+	case fixFiles:
+		return fixDriftedFiles(report, services, stored, coreConfig)
+	}
+	return nil
+}
+
+// Write the drifted keys to the files which set them last, the keys added to the store going to
+// the lowest layer of their service, and services without files being exported. Only the values
+// read as they are from a file are written back: a key interpolated, overridden by the environment
+// or read from an encrypted file would lose its template, its override or its encryption, and is
+// refused instead, the other keys being written.
+func fixDriftedFiles(report *driftReport, services []*serviceConfig, stored pkg.ConfigProperties, coreConfig pkg.CoreConfig) error {
+	isToml := func(path string) bool { return filepath.Ext(path) == ".toml" || isTomlExtension(coreConfig, path) }
+	files := map[string]pkg.ConfigProperties{}
+	paths := []string{}
+	unknown := pkg.ConfigProperties{}
+	refused := []string{}
+	for _, k := range report.Drifted {
+		service := serviceOfKey(services, k.Key)
+		if service == nil || len(service.Files) == 0 {
+			unknown[k.Key] = stored[k.Key]
+			continue
+		}
+		key := strings.TrimPrefix(k.Key, service.Prefix)
+
+		path := service.Files[0]
+		if origin, ok := service.Layers[key]; ok {
+			if strings.HasPrefix(origin, envOverridePrefix) {
+				refused = append(refused, k.Key+": set by the environment override "+origin)
+				continue
+			}
+			path = filepath.Join(filepath.Dir(path), origin)
+		}
+		if isEncryptedFile(path) {
+			refused = append(refused, k.Key+": read from an encrypted file")
+			continue
+		}
+		if !isToml(path) && filepath.Ext(path) != ".properties" {
+			refused = append(refused, k.Key+": "+filepath.Base(path)+" cannot be written")
+			continue
+		}
+
+		props, ok := files[path]
+		if !ok {
+			var err error
+			if isToml(path) {
+				props, err = readTomlFile(path)
+			} else {
+				props, err = readPropertiesFile(path)
+			}
+			if err != nil {
+				return err
+			}
+			files[path] = props
+			paths = append(paths, path)
+		}
+		if v, ok := service.Props[key]; ok && props[key] != v {
+			reason := "its value was resolved from " + strconv.Quote(secrets.Redact(k.Key, props[key]))
+			if referencePattern.MatchString(props[key]) {
+				reason = "interpolated from " + strconv.Quote(props[key])
+			}
+			refused = append(refused, k.Key+": "+reason)
+			continue
+		}
+
+		if v, ok := stored[k.Key]; ok {
+			props[key] = v
+		} else {
+			delete(props, key)
+		}
+	}
+
+	for _, path := range paths {
+		var err error
+		if isToml(path) {
+			err = writeTomlFile(path, filepath.Base(filepath.Dir(path)), files[path])
+		} else {
+			err = writePropertiesFile(path, files[path])
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Println("wrote the drifted keys back to", path)
+	}
+
+	grouped := groupByService(coreConfig.GlobalPrefix, stored)
+	for name := range groupByService(coreConfig.GlobalPrefix, unknown) {
+		if err := exportService("", name, grouped[name], coreConfig); err != nil {
+			return err
+		}
+	}
+
+	if len(refused) != 0 {
+		return fmt.Errorf("refusing to write drifted keys to the config files: %s", strings.Join(refused, "; "))
+	}
+	return nil
+}
+
+// The service a full key belongs to, the one with the longest prefix, nil when there is none.
+func serviceOfKey(services []*serviceConfig, key string) *serviceConfig {
+	var found *serviceConfig
+	for _, service := range services {
+		if strings.HasPrefix(key, service.Prefix) && (found == nil || len(service.Prefix) > len(found.Prefix)) {
+			found = service
+		}
+	}
+	return found
+}
+
+// The stored keys which differ from the config files, sorted by key. From the point of view of the
+// files, a key of the store is a change, and a plan from the files to the store lists them.
+func buildDriftReport(globalPrefix string, desired, stored pkg.ConfigProperties) *driftReport {
+	report := &driftReport{Time: time.Now().UTC(), Prefix: globalPrefix, Drifted: []driftedKey{}}
+	for _, e := range buildPlan(stored, desired, true) {
+		var change string
+		switch e.Action {
+		case planAdd:
+			change = "added"
+		case planChange:
+			change = "changed"
+		case planDelete:
+			change = "deleted"
+		default:
+			continue
+		}
+		report.Drifted = append(report.Drifted, driftedKey{Key: e.Key, Change: change,
+			File: secrets.Redact(e.Key, e.Old), Stored: secrets.Redact(e.Key, e.New)})
+	}
+	return report
+}
+
+// Print a report, then write it to the report file and post it to the webhook when given.
+func sendDriftReport(ctx context.Context, report *driftReport, d driftOptions) error {
+	if len(report.Drifted) == 0 {
+		fmt.Println("No drift under", report.Prefix)
+	} else {
+		fmt.Printf("Drift under %s: %d keys differ from the config files\n", report.Prefix, len(report.Drifted))
+		for _, k := range report.Drifted {
+			fmt.Printf("  %s %s: file %q, stored %q\n", k.Change, k.Key, k.File, k.Stored)
+		}
+	}
+
+	contents, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if d.Report != "" {
+		if err := ioutil.WriteFile(d.Report, contents, 0644); err != nil {
+			return err
+		}
+	}
+	if d.Webhook != "" {
+		req, err := http.NewRequest(http.MethodPost, d.Webhook, bytes.NewReader(contents))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := httpDo(http.DefaultClient, req.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("webhook %s answered %s", d.Webhook, resp.Status)
+		}
+	}
+	return nil
+}
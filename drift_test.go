@@ -0,0 +1,264 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg"
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg/secret"
+)
+
+// A memoryStore answering blocking queries at once.
+type watchingStore struct {
+	*memoryStore
+}
+
+func (s watchingStore) WatchPrefix(ctx context.Context, prefix string, index uint64) (map[string][]byte, uint64, error) {
+	values := map[string][]byte{}
+	for k, v := range s.data {
+		if strings.HasPrefix(k, prefix) {
+			values[k] = []byte(v)
+		}
+	}
+	return values, index + 1, nil
+}
+
+// Config files of one V2 service and a store where its keys were edited by hand.
+func newDriftFixture(t *testing.T) (pkg.CoreConfig, watchingStore, func()) {
+	root, err := ioutil.TempDir("", "drift")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{"v1", "v2/Svc"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	config := "[Service]\nHost = 'localhost'\nPort = 48080\n[Database]\nPassword = 's3cret'\n"
+	if err := ioutil.WriteFile(filepath.Join(root, "v2", "Svc", configDefault), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The V1 reader takes ConfigPath relative to the working directory.
+	wd, _ := os.Getwd()
+	rel, err := filepath.Rel(wd, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	coreConfig := pkg.CoreConfig{ConfigPath: filepath.Join(rel, "v1"), ConfigPathV2: filepath.Join(rel, "v2"),
+		GlobalPrefix: "config", IsReset: true}
+	s := watchingStore{newMemoryStore(map[string]string{
+		"config/Svc/Service/Port":      "48090",
+		"config/Svc/Database/Password": "hunter2",
+		"config/Svc/Extra":             "x",
+		"config/Svc/":                  "",
+	})}
+
+	saved := secrets
+	secrets, _ = secret.NewMatcher([]string{"(?i)password"})
+	return coreConfig, s, func() { secrets = saved; os.RemoveAll(root) }
+}
+
+func TestDriftReport(t *testing.T) {
+	coreConfig, s, cleanup := newDriftFixture(t)
+	defer cleanup()
+
+	var posted []byte
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posted, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer webhook.Close()
+	reportFile := filepath.Join(filepath.Dir(coreConfig.ConfigPathV2), "drift.json")
+
+	d := driftOptions{Report: reportFile, Webhook: webhook.URL, Once: true}
+	if err := driftConfig(context.Background(), "", coreConfig, s, d); err != nil {
+		t.Fatal(err)
+	}
+
+	written, err := ioutil.ReadFile(reportFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(written) != string(posted) {
+		t.Errorf("the report file and the webhook differ:\n%s\n%s", written, posted)
+	}
+	if strings.Contains(string(posted), "hunter2") || strings.Contains(string(posted), "s3cret") {
+		t.Errorf("secret values were reported: %s", posted)
+	}
+
+	report := driftReport{}
+	if err := json.Unmarshal(posted, &report); err != nil {
+		t.Fatal(err)
+	}
+	expected := []driftedKey{
+		{Key: "config/Svc/Database/Password", Change: "changed", File: secret.Redacted, Stored: secret.Redacted},
+		{Key: "config/Svc/Extra", Change: "added", Stored: "x"},
+		{Key: "config/Svc/Service/Host", Change: "deleted", File: "localhost"},
+		{Key: "config/Svc/Service/Port", Change: "changed", File: "48080", Stored: "48090"},
+	}
+	if report.Prefix != "config" || len(report.Drifted) != len(expected) {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	for i, k := range expected {
+		if report.Drifted[i] != k {
+			t.Errorf("expected %+v, got %+v", k, report.Drifted[i])
+		}
+	}
+}
+
+func TestDriftFixConsul(t *testing.T) {
+	coreConfig, s, cleanup := newDriftFixture(t)
+	defer cleanup()
+	coreConfig.SnapshotPath = filepath.Join(filepath.Dir(coreConfig.ConfigPathV2), "snapshots")
+
+	if err := driftConfig(context.Background(), "", coreConfig, s, driftOptions{Fix: fixConsul, Once: true}); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"config/Svc/Service/Host":      "localhost",
+		"config/Svc/Service/Port":      "48080",
+		"config/Svc/Database/Password": "s3cret",
+		"config/Svc/":                  "",
+	}
+	if len(s.data) != len(expected) {
+		t.Errorf("unexpected store %v", s.data)
+	}
+	for k, v := range expected {
+		if s.data[k] != v {
+			t.Errorf("%s: expected %q, got %q", k, v, s.data[k])
+		}
+	}
+
+	// The drifted tree was kept before it was fixed.
+	ids, err := listSnapshots(coreConfig, s)
+	if err != nil || len(ids) != 1 {
+		t.Fatalf("expected one snapshot, got %v (%v)", ids, err)
+	}
+	snap, err := readSnapshotFile(filepath.Join(coreConfig.SnapshotPath, ids[0]+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.Pairs["Svc/Service/Port"] != "48090" {
+		t.Errorf("the snapshot does not hold the drifted tree: %v", snap.Pairs)
+	}
+}
+
+func TestDriftFixFiles(t *testing.T) {
+	coreConfig, s, cleanup := newDriftFixture(t)
+	defer cleanup()
+
+	if err := driftConfig(context.Background(), "", coreConfig, s, driftOptions{Fix: fixFiles, Once: true}); err != nil {
+		t.Fatal(err)
+	}
+	props, err := readTomlFile(filepath.Join(coreConfig.ConfigPathV2, "Svc", configDefault))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := pkg.ConfigProperties{"Service/Port": "48090", "Database/Password": "hunter2", "Extra": "x"}
+	if len(props) != len(expected) {
+		t.Errorf("unexpected file %v", props)
+	}
+	for k, v := range expected {
+		if props[k] != v {
+			t.Errorf("%s: expected %q, got %q", k, v, props[k])
+		}
+	}
+}
+
+// Only the drifted keys are written back, keeping the templates and the overrides of the files.
+func TestDriftFixFilesKeepsResolvedValues(t *testing.T) {
+	coreConfig, s, cleanup := newDriftFixture(t)
+	defer cleanup()
+
+	path := filepath.Join(coreConfig.ConfigPathV2, "Svc", configDefault)
+	config := "[Service]\nHost = 'localhost'\nPort = 48080\nName = 'svc-${Service.Port}'\n[Database]\nPassword = 's3cret'\n"
+	if err := ioutil.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer func(f func() []string) { environ = f }(environ)
+	environ = func() []string { return []string{"EDGEX_SEED__Svc__Database__Password=fromenv"} }
+	s.data = map[string]string{
+		"config/Svc/Service/Host":      "edgex-svc",
+		"config/Svc/Service/Port":      "48080",
+		"config/Svc/Service/Name":      "svc-1",
+		"config/Svc/Database/Password": "hunter2",
+		"config/Svc/Extra":             "x",
+	}
+
+	err := driftConfig(context.Background(), "", coreConfig, s, driftOptions{Fix: fixFiles, Once: true})
+	if err == nil || !strings.Contains(err.Error(), `config/Svc/Service/Name: interpolated from "svc-${Service.Port}"`) ||
+		!strings.Contains(err.Error(), "config/Svc/Database/Password: set by the environment override EDGEX_SEED__Svc__Database__Password") {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	props, err := readTomlFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := pkg.ConfigProperties{"Service/Host": "edgex-svc", "Service/Port": "48080",
+		"Service/Name": "svc-${Service.Port}", "Database/Password": "s3cret", "Extra": "x"}
+	if !reflect.DeepEqual(props, expected) {
+		t.Errorf("expected %v, got %v", expected, props)
+	}
+}
+
+func TestDriftNeedsBlockingQueries(t *testing.T) {
+	err := driftConfig(context.Background(), "", pkg.CoreConfig{StoreType: "file"}, newMemoryStore(nil), driftOptions{Once: true})
+	if exitCode(err) != exitConfigLoad {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+// A report which could not be delivered is sent again, and the drift is fixed all the same.
+func TestDriftFailedDelivery(t *testing.T) {
+	coreConfig, s, cleanup := newDriftFixture(t)
+	defer cleanup()
+
+	posts := 0
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer webhook.Close()
+
+	d := driftOptions{Webhook: webhook.URL, Fix: fixConsul, Once: true}
+	if err := driftConfig(context.Background(), "", coreConfig, s, d); err == nil {
+		t.Fatal("expected the failed delivery to be returned")
+	}
+	if s.data["config/Svc/Service/Port"] != "48080" {
+		t.Errorf("the drift was not fixed: %v", s.data)
+	}
+
+	var last *driftReport
+	s.data["config/Svc/Service/Port"] = "48090"
+	values, _, _ := s.WatchPrefix(context.Background(), "config/", 0)
+	for i := 0; i < 2; i++ {
+		if err := checkDrift(context.Background(), values, "", coreConfig, s, driftOptions{Webhook: webhook.URL}, &last); err == nil {
+			t.Fatal("expected the failed delivery to be returned")
+		}
+	}
+	if last != nil || posts != 3 {
+		t.Errorf("expected every failed report to be sent again, got %d posts and last %v", posts, last)
+	}
+}
@@ -0,0 +1,148 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg"
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg/crypt"
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg/secret"
+)
+
+// Environment variables giving the age key when the -age-key flag is not set:
+// the path of a key file, or the key itself.
+const (
+	ageKeyFileEnv = "EDGEX_SEED_AGE_KEY_FILE"
+	ageKeyEnv     = "EDGEX_SEED_AGE_KEY"
+)
+
+// Decrypts the encrypted values and files of the configuration, nil when no key was given.
+var decrypter *crypt.Decrypter
+
+// The ENC[age,...] values decrypted by full key, written back to the config files in place of
+// their plaintext. Keys read from encrypted files are held with an empty ciphertext.
+var ciphertexts = map[string]string{}
+
+// Read the age key from a file, or from the environment when file is empty.
+// It returns nil when no key is given at all.
+func newDecrypter(file string) (*crypt.Decrypter, error) {
+	if file == "" {
+		file = os.Getenv(ageKeyFileEnv)
+	}
+	if file != "" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return crypt.NewDecrypter(f)
+	}
+	if key := os.Getenv(ageKeyEnv); key != "" {
+		return crypt.NewDecrypter(strings.NewReader(key))
+	}
+	return nil, nil
+}
+
+// Read a configuration file, decrypting it in memory when it is encrypted with age.
+// An encrypted file which cannot be decrypted is an error, never returned as ciphertext.
+func readConfigFile(path string) ([]byte, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil || !crypt.IsEncryptedFile(contents) {
+		return contents, withExitCode(exitConfigLoad, err)
+	}
+	if decrypter == nil {
+		return nil, withExitCode(exitConfigLoad, fmt.Errorf("%s is encrypted but no age key was given, use -age-key or %s", path, ageKeyFileEnv))
+	}
+
+	plaintext, err := decrypter.DecryptFile(contents)
+	if err != nil {
+		return nil, withExitCode(exitConfigLoad, fmt.Errorf("could not decrypt %s: %v", path, err))
+	}
+	return plaintext, nil
+}
+
+// Whether a configuration file is encrypted with age. Every key read from such a file is a secret.
+func isEncryptedFile(path string) bool {
+	contents, err := ioutil.ReadFile(path)
+	return err == nil && crypt.IsEncryptedFile(contents)
+}
+
+// Decrypt the ENC[age,...] values of a service in memory and mark their keys as secrets.
+// Values which cannot be decrypted are errors, reported for every key at once.
+func decryptValues(service *serviceConfig) error {
+	problems := []string{}
+	for k, v := range service.Props {
+		if !crypt.IsEncryptedValue(v) {
+			continue
+		}
+		if decrypter == nil {
+			problems = append(problems, k+": encrypted but no age key was given, use -age-key or "+ageKeyFileEnv)
+			continue
+		}
+		plaintext, err := decrypter.DecryptValue(v)
+		if err != nil {
+			problems = append(problems, k+": "+err.Error())
+			continue
+		}
+		service.Props[k] = plaintext
+		secrets.Add(service.Prefix + k)
+		ciphertexts[service.Prefix+k] = v
+	}
+
+	if len(problems) != 0 {
+		return withExitCode(exitConfigLoad, fmt.Errorf("could not decrypt %s: %s", service.Dir, strings.Join(problems, "; ")))
+	}
+	return nil
+}
+
+// Put the ciphertexts back in place of the decrypted values of a service before it is written to
+// a file, keys being relative to prefix, so that no secret is written in plaintext. References to
+// Vault stand for the ciphertext they were seeded from. A value read from an encrypted file, or
+// changed since it was decrypted, cannot be encrypted again and is an error.
+func restoreCiphertexts(prefix string, props pkg.ConfigProperties) (pkg.ConfigProperties, error) {
+	restored := pkg.ConfigProperties{}
+	problems := []string{}
+	for k, v := range props {
+		ciphertext, ok := ciphertexts[prefix+k]
+		switch {
+		case !ok:
+			restored[k] = v
+		case ciphertext == "":
+			problems = append(problems, k+": read from an encrypted file")
+		case secret.IsReference(v) || decryptsTo(ciphertext, v):
+			restored[k] = ciphertext
+		default:
+			problems = append(problems, k+": changed since it was decrypted")
+		}
+	}
+
+	if len(problems) != 0 {
+		sort.Strings(problems)
+		return nil, fmt.Errorf("refusing to write the secrets of %s in plaintext: %s", prefix, strings.Join(problems, "; "))
+	}
+	return restored, nil
+}
+
+func decryptsTo(ciphertext string, value string) bool {
+	if decrypter == nil {
+		return false
+	}
+	plaintext, err := decrypter.DecryptValue(ciphertext)
+	return err == nil && plaintext == value
+}
@@ -0,0 +1,177 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg"
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg/crypt"
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg/secret"
+)
+
+// Use a new age key for the duration of a test, returning its recipient and a cleanup.
+func useTestAgeKey(t *testing.T) (age.Recipient, func()) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, cleanup := writeTestFile(t, "key.txt", identity.String()+"\n")
+	defer cleanup()
+
+	saved, savedSecrets, savedCiphertexts := decrypter, secrets, ciphertexts
+	decrypter, err = newDecrypter(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets, ciphertexts = &secret.Matcher{}, map[string]string{}
+	return identity.Recipient(), func() { decrypter, secrets, ciphertexts = saved, savedSecrets, savedCiphertexts }
+}
+
+func TestReadEncryptedFile(t *testing.T) {
+	recipient, restore := useTestAgeKey(t)
+	defer restore()
+
+	var b bytes.Buffer
+	a := armor.NewWriter(&b)
+	w, err := age.Encrypt(a, recipient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("server:\n  port: 8080\n  password: p4ss\n"))
+	w.Close()
+	a.Close()
+
+	path, cleanup := writeTestFile(t, "application.yaml", b.String())
+	defer cleanup()
+
+	coreConfig := pkg.CoreConfig{YamlExtensions: []string{".yaml"}}
+	props, err := readPropertyFile(coreConfig, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if props["server/port"] != "8080" || props["server/password"] != "p4ss" {
+		t.Errorf("unexpected properties %v", props)
+	}
+	if !isEncryptedFile(path) {
+		t.Error("file not detected as encrypted")
+	}
+
+	// Without a key the file fails instead of being read as ciphertext.
+	decrypter = nil
+	if _, err := readPropertyFile(coreConfig, path); err == nil || !strings.Contains(err.Error(), "no age key") {
+		t.Errorf("expected a missing key error, got %v", err)
+	}
+}
+
+func TestDecryptValues(t *testing.T) {
+	recipient, restore := useTestAgeKey(t)
+	defer restore()
+
+	password, err := crypt.EncryptValue("p4ss", recipient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, cleanup := writeTestFile(t, "application.properties", "MongoDBUserName=admin\nMongoDBCredential="+password+"\n")
+	defer cleanup()
+
+	props, err := readPropertyFile(pkg.CoreConfig{}, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := &serviceConfig{Dir: "edgex-core-data/", Prefix: "config/edgex-core-data/", Props: props}
+	if err := decryptValues(service); err != nil {
+		t.Fatal(err)
+	}
+	if service.Props["MongoDBCredential"] != "p4ss" || service.Props["MongoDBUserName"] != "admin" {
+		t.Errorf("unexpected properties %v", service.Props)
+	}
+	if !secrets.IsSecret("config/edgex-core-data/MongoDBCredential") || secrets.IsSecret("config/edgex-core-data/MongoDBUserName") {
+		t.Error("decrypted keys must be secrets")
+	}
+
+	// A value of another key fails the service.
+	other, _ := age.GenerateX25519Identity()
+	foreign, err := crypt.EncryptValue("p4ss", other.Recipient())
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.Props["MongoDBCredential"] = foreign
+	if err := decryptValues(service); err == nil || !strings.Contains(err.Error(), "MongoDBCredential") {
+		t.Errorf("expected a decryption error, got %v", err)
+	}
+}
+
+// Decrypted values are validated: an encrypted int field is valid when it decrypts to an int.
+func TestValidateDecryptedValues(t *testing.T) {
+	recipient, restore := useTestAgeKey(t)
+	defer restore()
+	root, err := ioutil.TempDir("", "encrypted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	port, err := crypt.EncryptValue("48090", recipient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeCoreDataConfig(t, root, "Port = 48082", "Port = '"+port+"'")
+
+	services, err := readV2ConfigFromPath("", pkg.CoreConfig{ConfigPathV2: root, GlobalPrefix: "config"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if services[0].Props["Service/Port"] != "48090" {
+		t.Errorf("unexpected port %q", services[0].Props["Service/Port"])
+	}
+	if ciphertexts["config/EdgeX_Core_Data/Service/Port"] != port {
+		t.Error("the ciphertext of the port must be kept")
+	}
+}
+
+func TestRestoreCiphertexts(t *testing.T) {
+	recipient, restore := useTestAgeKey(t)
+	defer restore()
+
+	password, err := crypt.EncryptValue("p4ss", recipient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertexts["config/svc/Password"] = password
+	ciphertexts["config/svc/Token"] = ""
+
+	for _, stored := range []string{"p4ss", "vault:secret/edgex/svc#Password"} {
+		props, err := restoreCiphertexts("config/svc/", pkg.ConfigProperties{"Password": stored, "Host": "localhost"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(props, pkg.ConfigProperties{"Password": password, "Host": "localhost"}) {
+			t.Errorf("%s: unexpected properties %v", stored, props)
+		}
+	}
+
+	_, err = restoreCiphertexts("config/svc/", pkg.ConfigProperties{"Password": "changed", "Token": "t0ken"})
+	if err == nil || err.Error() != "refusing to write the secrets of config/svc/ in plaintext: "+
+		"Password: changed since it was decrypted; Token: read from an encrypted file" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Prefix of the environment variables overriding a key at seed time, followed by the service
+// directory and the path of the key separated by double underscores, e.g.
+// EDGEX_SEED__EdgeX_Core_Data__Database__Host=mongo sets Database/Host of EdgeX_Core_Data.
+const envOverridePrefix = "EDGEX_SEED__"
+
+// Hook the environment for the tests.
+var environ = os.Environ
+
+// A key of a service set by an environment variable.
+type envOverride struct {
+	Name    string
+	Service string
+	Key     string
+	Value   string
+}
+
+// Read the overrides from the environment, sorted by variable name.
+func readEnvOverrides() []envOverride {
+	overrides := []envOverride{}
+	for _, e := range environ() {
+		i := strings.Index(e, "=")
+		if i < 0 || !strings.HasPrefix(e[:i], envOverridePrefix) {
+			continue
+		}
+		name, value := e[:i], e[i+1:]
+
+		parts := strings.Split(strings.TrimPrefix(name, envOverridePrefix), "__")
+		if len(parts) < 2 || hasEmpty(parts) {
+			fmt.Fprintln(logOutput, "ignoring environment override", name, "which does not name a service and a key")
+			continue
+		}
+		overrides = append(overrides, envOverride{Name: name, Service: parts[0], Key: strings.Join(parts[1:], "/"), Value: value})
+	}
+
+	sort.Slice(overrides, func(i, j int) bool { return overrides[i].Name < overrides[j].Name })
+	return overrides
+}
+
+func hasEmpty(parts []string) bool {
+	for _, p := range parts {
+		if p == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Normalize a service directory or key for matching against a variable name: directories and keys
+// can hold characters such as '-', ';' or '.' which variable names cannot, so these match an
+// underscore. Case is ignored.
+func envName(s string) string {
+	return strings.ToUpper(strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '/' {
+			return r
+		}
+		return '_'
+	}, s))
+}
+
+// Apply the overrides of a service to its properties. A variable replaces the key it matches,
+// or adds its key path as given when no key matches. dirs holds the directories of the services
+// seen so far by normalized name: a variable matching the name of two services or two keys
+// cannot tell them apart, which is reported instead of applying it to both.
+func applyEnvOverrides(service *serviceConfig, overrides []envOverride, dirs map[string]string) error {
+	dir := envName(strings.TrimSuffix(service.Dir, "/"))
+	other, collides := dirs[dir]
+	dirs[dir] = service.Dir
+	for _, o := range overrides {
+		if envName(o.Service) != dir {
+			continue
+		}
+		if collides && other != service.Dir {
+			return fmt.Errorf("environment override %s matches both services %s and %s", o.Name, other, service.Dir)
+		}
+
+		matches := []string{}
+		for k := range service.Props {
+			if envName(k) == envName(o.Key) {
+				matches = append(matches, k)
+			}
+		}
+		sort.Strings(matches)
+		if len(matches) > 1 {
+			return fmt.Errorf("environment override %s matches the keys %s of %s", o.Name, strings.Join(matches, ", "), service.Dir)
+		}
+
+		key := o.Key
+		if len(matches) == 1 {
+			key = matches[0]
+		}
+		service.Props[key] = o.Value
+		if service.Layers != nil {
+			service.Layers[key] = o.Name
+		}
+		if len(matches) == 0 {
+			fmt.Fprintln(logOutput, "environment override", o.Name, "matches no key and adds", service.Prefix+key)
+			continue
+		}
+		fmt.Fprintln(logOutput, "environment override", o.Name, "applied to", service.Prefix+key)
+	}
+	return nil
+}
+
+// Warn about every override matching none of the services read, such as a misspelled service
+// directory, which is not applied at all. It returns the names of these overrides.
+func warnUnmatchedOverrides(overrides []envOverride, services []*serviceConfig) []string {
+	dirs := map[string]bool{}
+	for _, service := range services {
+		dirs[envName(strings.TrimSuffix(service.Dir, "/"))] = true
+	}
+	unmatched := []string{}
+	for _, o := range overrides {
+		if !dirs[envName(o.Service)] {
+			fmt.Fprintln(logOutput, "warning: environment override", o.Name, "matches no service and is not applied")
+			unmatched = append(unmatched, o.Name)
+		}
+	}
+	return unmatched
+}
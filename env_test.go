@@ -0,0 +1,156 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg"
+)
+
+func TestApplyEnvOverrides(t *testing.T) {
+	defer func(f func() []string) { environ = f }(environ)
+	environ = func() []string {
+		return []string{
+			"PATH=/usr/bin",
+			"EDGEX_SEED__EdgeX_Core_Data__Database__Host=mongo",
+			"EDGEX_SEED__EdgeX_Core_Data__Database__Password=secret=42",
+			"EDGEX_SEED__edgex_core_data_docker__server_port=48090",
+			"EDGEX_SEED__EdgeX_Core_Data",
+			"EDGEX_SEED__EdgeX_Core_Data____Host=ignored",
+		}
+	}
+	overrides := readEnvOverrides()
+	if len(overrides) != 3 {
+		t.Fatalf("expected 3 overrides, got %+v", overrides)
+	}
+
+	v2 := &serviceConfig{
+		Dir:    "EdgeX_Core_Data/",
+		Prefix: "config/EdgeX_Core_Data/",
+		Props:  pkg.ConfigProperties{"Database/Host": "localhost", "Database/Port": "27017"},
+		Layers: map[string]string{"Database/Host": "configuration.toml", "Database/Port": "configuration.toml"},
+	}
+	v1 := &serviceConfig{
+		Dir:    "edgex-core-data;docker/",
+		Prefix: "config/edgex-core-data;docker/",
+		Props:  pkg.ConfigProperties{"server.port": "48080"},
+	}
+	dirs := map[string]string{}
+	for _, service := range []*serviceConfig{v2, v1} {
+		if err := applyEnvOverrides(service, overrides, dirs); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := pkg.ConfigProperties{"Database/Host": "mongo", "Database/Port": "27017", "Database/Password": "secret=42"}
+	if !reflect.DeepEqual(v2.Props, expected) {
+		t.Errorf("expected %v, got %v", expected, v2.Props)
+	}
+	if v2.Layers["Database/Host"] != "EDGEX_SEED__EdgeX_Core_Data__Database__Host" {
+		t.Errorf("unexpected layer %q", v2.Layers["Database/Host"])
+	}
+	if !reflect.DeepEqual(v1.Props, pkg.ConfigProperties{"server.port": "48090"}) {
+		t.Errorf("unexpected V1 properties %v", v1.Props)
+	}
+}
+
+func TestEnvOverrideCollisions(t *testing.T) {
+	overrides := []envOverride{{Name: "EDGEX_SEED__EdgeX_Core_Data__Service__Port", Service: "EdgeX_Core_Data", Key: "Service/Port", Value: "48090"}}
+
+	dirs := map[string]string{}
+	data := &serviceConfig{Dir: "EdgeX_Core_Data/", Props: pkg.ConfigProperties{"Service/Port": "48080"}}
+	if err := applyEnvOverrides(data, overrides, dirs); err != nil {
+		t.Fatal(err)
+	}
+	other := &serviceConfig{Dir: "edgex-core-data/", Props: pkg.ConfigProperties{"Service/Port": "48080"}}
+	err := applyEnvOverrides(other, overrides, dirs)
+	if err == nil || !strings.Contains(err.Error(), "matches both services EdgeX_Core_Data/ and edgex-core-data/") {
+		t.Errorf("expected the services to collide, got %v", err)
+	}
+	if other.Props["Service/Port"] != "48080" {
+		t.Error("a colliding override must not be applied")
+	}
+
+	keys := &serviceConfig{Dir: "EdgeX_Core_Data/", Props: pkg.ConfigProperties{"Service/Port": "48080", "service/port": "48081"}}
+	err = applyEnvOverrides(keys, overrides, map[string]string{})
+	if err == nil || !strings.Contains(err.Error(), "matches the keys Service/Port, service/port of EdgeX_Core_Data/") {
+		t.Errorf("expected the keys to collide, got %v", err)
+	}
+}
+
+// Overrides matching no service are reported, and overrides matching no key are logged as added.
+func TestUnmatchedEnvOverrides(t *testing.T) {
+	defer func(w io.Writer) { logOutput = w }(logOutput)
+	var log bytes.Buffer
+	logOutput = &log
+
+	overrides := []envOverride{
+		{Name: "EDGEX_SEED__EdgeX_Core_Data__Service__Port", Service: "EdgeX_Core_Data", Key: "Service/Port", Value: "48090"},
+		{Name: "EDGEX_SEED__EdgeX_Core_Data__Servce__Host", Service: "EdgeX_Core_Data", Key: "Servce/Host", Value: "edgex"},
+		{Name: "EDGEX_SEED__EdgeX_Core_Dta__Service__Port", Service: "EdgeX_Core_Dta", Key: "Service/Port", Value: "48090"},
+	}
+	data := &serviceConfig{Dir: "EdgeX_Core_Data/", Prefix: "config/EdgeX_Core_Data/", Props: pkg.ConfigProperties{"Service/Port": "48080"}}
+	if err := applyEnvOverrides(data, overrides, map[string]string{}); err != nil {
+		t.Fatal(err)
+	}
+	unmatched := warnUnmatchedOverrides(overrides, []*serviceConfig{data})
+	if !reflect.DeepEqual(unmatched, []string{"EDGEX_SEED__EdgeX_Core_Dta__Service__Port"}) {
+		t.Errorf("unexpected unmatched overrides %v", unmatched)
+	}
+	for _, line := range []string{
+		"environment override EDGEX_SEED__EdgeX_Core_Data__Service__Port applied to config/EdgeX_Core_Data/Service/Port",
+		"environment override EDGEX_SEED__EdgeX_Core_Data__Servce__Host matches no key and adds config/EdgeX_Core_Data/Servce/Host",
+		"warning: environment override EDGEX_SEED__EdgeX_Core_Dta__Service__Port matches no service and is not applied",
+	} {
+		if !strings.Contains(log.String(), line) {
+			t.Errorf("missing %q in:\n%s", line, log.String())
+		}
+	}
+}
+
+// Overridden values are validated: an override of an int field must be an int.
+func TestValidateEnvOverrides(t *testing.T) {
+	defer func(f func() []string) { environ = f }(environ)
+	root, err := ioutil.TempDir("", "env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	writeCoreDataConfig(t, root, "", "")
+
+	coreConfig := pkg.CoreConfig{ConfigPathV2: root, GlobalPrefix: "config"}
+	for port, valid := range map[string]bool{"48090": true, "abc": false} {
+		environ = func() []string { return []string{"EDGEX_SEED__EdgeX_Core_Data__Service__Port=" + port} }
+		services, err := readV2ConfigFromPath("", coreConfig)
+		if !valid {
+			if exitCode(err) != exitValidation {
+				t.Errorf("%s: expected a validation error, got %v", port, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", port, err)
+		}
+		if services[0].Props["Service/Port"] != port {
+			t.Errorf("%s: unexpected port %q", port, services[0].Props["Service/Port"])
+		}
+	}
+}
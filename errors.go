@@ -0,0 +1,71 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package main
+
+import "errors"
+
+// Exit codes of the seeder, documented in the README. Orchestration relies on them to tell a
+// failed seed from a successful one, so never renumber them.
+const (
+	exitOK = iota
+	// exitFailure is any error not classified below, e.g. a bad command line.
+	exitFailure
+	// exitConfigLoad is the configuration of the seeder or a config file which cannot be read or decrypted.
+	exitConfigLoad
+	// exitStoreUnreachable is Consul, or the K/V store selected by StoreType, not becoming ready.
+	exitStoreUnreachable
+	// exitParse is a config file which cannot be parsed.
+	exitParse
+	// exitValidation is a configuration not matching the type of its service or with unresolved references.
+	exitValidation
+	// exitPartialWrite is a write to the store which failed or could not be verified, so some keys
+	// may have been written and others not.
+	exitPartialWrite
+)
+
+// An error classified by the exit code it ends the seeder with.
+type seedError struct {
+	code int
+	err  error
+}
+
+func (e *seedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *seedError) Unwrap() error {
+	return e.err
+}
+
+// Classify err with an exit code. Nil stays nil and an error classified already keeps its code,
+// the place where it happened knowing best what went wrong.
+func withExitCode(code int, err error) error {
+	var se *seedError
+	if err == nil || errors.As(err, &se) {
+		return err
+	}
+	return &seedError{code: code, err: err}
+}
+
+// The exit code for err, exitFailure when it was never classified.
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	var se *seedError
+	if errors.As(err, &se) {
+		return se.code
+	}
+	return exitFailure
+}
@@ -0,0 +1,154 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg"
+)
+
+func TestExitCode(t *testing.T) {
+	if withExitCode(exitParse, nil) != nil {
+		t.Error("nil must stay nil")
+	}
+	if code := exitCode(nil); code != exitOK {
+		t.Errorf("expected %d for nil, got %d", exitOK, code)
+	}
+	if code := exitCode(errors.New("plain")); code != exitFailure {
+		t.Errorf("expected %d for an unclassified error, got %d", exitFailure, code)
+	}
+
+	err := withExitCode(exitParse, errors.New("bad file"))
+	if err.Error() != "bad file" {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+	// The first classification wins, also through wrapping.
+	err = withExitCode(exitPartialWrite, fmt.Errorf("reading: %w", err))
+	if code := exitCode(err); code != exitParse {
+		t.Errorf("expected %d, got %d", exitParse, code)
+	}
+}
+
+// A store failing every write.
+type failingStore struct {
+	*memoryStore
+}
+
+func (s failingStore) Put(key string, value []byte) error {
+	return errors.New("write refused")
+}
+
+func TestSeedExitCodes(t *testing.T) {
+	root, err := ioutil.TempDir("", "exit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	tests := []struct {
+		name    string
+		service string
+		config  string
+		store   failingStore
+		code    int
+	}{
+		{"parse", "Svc", "[Service\nPort = 48080\n", failingStore{}, exitParse},
+		{"validation", "EdgeX_Core_Data", "[Service]\nPort = 'not a number'\n", failingStore{}, exitValidation},
+		{"reference", "Svc", "[Service]\nHost = '${missing}'\n", failingStore{}, exitValidation},
+		{"partial write", "Svc", "[Service]\nPort = 48080\n", failingStore{newMemoryStore(nil)}, exitPartialWrite},
+	}
+	for _, test := range tests {
+		dir := filepath.Join(root, test.name, test.service)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, configDefault), []byte(test.config), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		coreConfig := pkg.CoreConfig{ConfigPathV2: filepath.Join(root, test.name), GlobalPrefix: "config"}
+		services, err := readV2ConfigFromPath("", coreConfig)
+		if err == nil {
+			err = putV2Services(services, coreConfig, test.store)
+		}
+		if code := exitCode(err); code != test.code {
+			t.Errorf("%s: expected exit code %d, got %d (%v)", test.name, test.code, code, err)
+		}
+	}
+}
+
+// A reset only clears the stored tree once every V2 and V1 file has been read and validated.
+func TestResetKeepsTreeOnBadFiles(t *testing.T) {
+	root, err := ioutil.TempDir("", "reset")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	tests := []struct {
+		name  string
+		files map[string]string
+		code  int
+	}{
+		{"invalid V2", map[string]string{"v2/EdgeX_Core_Data/" + configDefault: "[Service]\nPort = 'not a number'\n"}, exitValidation},
+		{"unparsable V1", map[string]string{
+			"v2/Svc/" + configDefault:    "[Service]\nPort = 48080\n",
+			"v1/device/application.json": "{\"server.port\": ",
+		}, exitParse},
+	}
+	for _, test := range tests {
+		dir := filepath.Join(root, strings.Replace(test.name, " ", "-", -1))
+		for name, contents := range test.files {
+			path := filepath.Join(dir, name)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := os.MkdirAll(filepath.Join(dir, "v1"), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		coreConfig := pkg.CoreConfig{ConfigPath: filepath.Join(dir, "v1"), ConfigPathV2: filepath.Join(dir, "v2"),
+			GlobalPrefix: "config", IsReset: true,
+			AcceptablePropertyExtensions: []string{".json"}, JsonExtensions: []string{".json"}}
+		s := newMemoryStore(map[string]string{"config/Svc/Service/Port": "48090"})
+		err := seedConfig("", coreConfig, s)
+		if code := exitCode(err); code != test.code {
+			t.Errorf("%s: expected exit code %d, got %d (%v)", test.name, test.code, code, err)
+		}
+		if len(s.data) != 1 || s.data["config/Svc/Service/Port"] != "48090" {
+			t.Errorf("%s: the stored tree changed: %v", test.name, s.data)
+		}
+	}
+}
+
+func TestMissingConfigPathExitCodes(t *testing.T) {
+	coreConfig := pkg.CoreConfig{ConfigPath: "./missing", ConfigPathV2: "./missing", GlobalPrefix: "config"}
+	if _, err := readV2ConfigFromPath("", coreConfig); exitCode(err) != exitConfigLoad {
+		t.Errorf("V2: expected exit code %d, got %v", exitConfigLoad, err)
+	}
+	if _, err := readConfigFromPath("", coreConfig); exitCode(err) != exitConfigLoad {
+		t.Errorf("V1: expected exit code %d, got %v", exitConfigLoad, err)
+	}
+}
@@ -0,0 +1,230 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg"
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg/store"
+	"github.com/edgexfoundry/core-config-seed-go/pkg/v2/client"
+	"github.com/edgexfoundry/core-config-seed-go/pkg/v2/types"
+	"github.com/magiconair/properties"
+	"github.com/pelletier/go-toml"
+)
+
+// V1 services without a TOML file are exported to this file.
+const exportPropertiesFile = "application.properties"
+
+// Rebuild the config directories from the keys stored under the global prefix.
+// V2 services, the directories found in ConfigPathV2, are written as hierarchical TOML to their
+// base configuration.toml, the profile files being left as they are. Every other service is a V1 service and is written to the
+// TOML file of its directory in ConfigPath when there is one, to application.properties otherwise.
+// root, when not empty, is prepended to both paths. When root names a .json file, a snapshot
+// file holding every key is written instead.
+func exportConfig(root string, profile string, coreConfig pkg.CoreConfig, s store.ConfigStore) error {
+	stored, err := storedConfig(coreConfig, s)
+	if err != nil {
+		return err
+	}
+
+	if filepath.Ext(root) == ".json" {
+		snap := newSnapshot(coreConfig.GlobalPrefix, stored)
+		if err := writeSnapshotFile(root, snap); err != nil {
+			return err
+		}
+		fmt.Println("exported", len(snap.Pairs), "keys to snapshot", root)
+		return nil
+	}
+
+	// The config files tell which values were decrypted, to write their ciphertexts back.
+	if _, err := readServices(profile, coreConfig); err != nil {
+		return err
+	}
+
+	services := groupByService(coreConfig.GlobalPrefix, stored)
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := exportService(root, name, services[name], coreConfig); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Write the keys of a service, relative to its directory, to its config file as exportConfig does.
+// The decrypted values are written as their ciphertexts.
+func exportService(root string, name string, props pkg.ConfigProperties, coreConfig pkg.CoreConfig) error {
+	props, err := restoreCiphertexts(coreConfig.GlobalPrefix+"/"+name+"/", props)
+	if err != nil {
+		return err
+	}
+
+	var path string
+	if listDirs(coreConfig.ConfigPathV2)[name] {
+		path = filepath.Join(root, coreConfig.ConfigPathV2, name, configDefault)
+		err = writeTomlFile(path, name, props)
+	} else if file := findTomlFile(coreConfig, filepath.Join(coreConfig.ConfigPath, name)); file != "" {
+		path = filepath.Join(root, coreConfig.ConfigPath, name, file)
+		err = writeTomlFile(path, name, props)
+	} else {
+		path = filepath.Join(root, coreConfig.ConfigPath, name, exportPropertiesFile)
+		err = writePropertiesFile(path, props)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println("exported", len(props), "keys of", name, "to", path)
+	return nil
+}
+
+// Split full keys by the service directory directly under the global prefix,
+// keeping the keys relative to the service.
+func groupByService(globalPrefix string, props pkg.ConfigProperties) map[string]pkg.ConfigProperties {
+	services := map[string]pkg.ConfigProperties{}
+	for k, v := range props {
+		parts := strings.SplitN(strings.TrimPrefix(k, globalPrefix+"/"), "/", 2)
+		if len(parts) < 2 {
+			continue
+		}
+		if services[parts[0]] == nil {
+			services[parts[0]] = pkg.ConfigProperties{}
+		}
+		services[parts[0]][parts[1]] = v
+	}
+	return services
+}
+
+// Names of the directories directly under path.
+func listDirs(path string) map[string]bool {
+	dirs := map[string]bool{}
+	infos, err := ioutil.ReadDir(path)
+	if err != nil {
+		return dirs
+	}
+	for _, info := range infos {
+		if info.IsDir() {
+			dirs[info.Name()] = true
+		}
+	}
+	return dirs
+}
+
+// Name of the first TOML file in dir, empty when there is none.
+func findTomlFile(coreConfig pkg.CoreConfig, dir string) string {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	for _, info := range infos {
+		if !info.IsDir() && isTomlExtension(coreConfig, info.Name()) {
+			return info.Name()
+		}
+	}
+	return ""
+}
+
+// Write flattened keys as hierarchical TOML, "Service/Port" becomes "Port" in "[Service]".
+// The values of a registered V2 service take the types of the fields of its struct.
+func writeTomlFile(path string, service string, props pkg.ConfigProperties) error {
+	config, err := client.Unflatten(props)
+	if err != nil {
+		return fmt.Errorf("could not export %s: %v", service, err)
+	}
+	if st, ok := types.Lookup(service); ok {
+		st.TypeValues(config)
+	} else {
+		typeValues(config)
+	}
+
+	tree, err := toml.TreeFromMap(config)
+	if err != nil {
+		return err
+	}
+	contents, err := tree.ToTomlString()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, []byte(contents), 0644)
+}
+
+// Write keys as a .properties file, sorted by key.
+func writePropertiesFile(path string, props pkg.ConfigProperties) error {
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	p := properties.NewProperties()
+	for _, k := range keys {
+		if _, _, err := p.Set(k, props[k]); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = p.Write(file, properties.UTF8)
+	return err
+}
+
+// Consul keeps strings only. Without a struct to tell the type a value had in the configuration
+// file, a value is typed back to an integer, a float or a boolean only when it reads the same once
+// typed, so that "48080" becomes 48080 but "007", "+5" and "1.10" stay strings.
+func typeValues(v interface{}) interface{} {
+	switch n := v.(type) {
+	case map[string]interface{}:
+		for k, sv := range n {
+			n[k] = typeValues(sv)
+		}
+	case []interface{}:
+		for i, sv := range n {
+			n[i] = typeValues(sv)
+		}
+	case string:
+		if i, err := strconv.ParseInt(n, 10, 64); err == nil && strconv.FormatInt(i, 10) == n {
+			return i
+		}
+		if f, err := strconv.ParseFloat(n, 64); err == nil && strings.Contains(n, ".") && strconv.FormatFloat(f, 'f', -1, 64) == n {
+			return f
+		}
+		if n == "true" || n == "false" {
+			return n == "true"
+		}
+	}
+	return v
+}
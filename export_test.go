@@ -0,0 +1,123 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg"
+)
+
+func TestExportConfig(t *testing.T) {
+	root, err := ioutil.TempDir("", "export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	coreConfig := pkg.CoreConfig{
+		ConfigPath:     "./config",
+		ConfigPathV2:   "./pkg/v2/toml",
+		GlobalPrefix:   "config",
+		TomlExtensions: []string{".toml"},
+	}
+	s := newMemoryStore(map[string]string{
+		"config/EdgeX_Core_Data/Service/Port":      "48080",
+		"config/EdgeX_Core_Data/Service/Host":      "localhost",
+		"config/EdgeX_Core_Data/Devices/0/Name":    "first",
+		"config/EdgeX_Core_Data/Devices/1/Name":    "second",
+		"config/edgex-core-data;go/ServicePort":    "48080",
+		"config/device-virtual/application.name":   "device-virtual",
+		"config/device-virtual;docker/server.port": "49990",
+	})
+
+	if err := exportConfig(root, "", coreConfig, s); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path     string
+		read     func(string) (pkg.ConfigProperties, error)
+		expected pkg.ConfigProperties
+	}{
+		{"pkg/v2/toml/EdgeX_Core_Data/configuration.toml", readTomlFile, pkg.ConfigProperties{
+			"Service/Port": "48080", "Service/Host": "localhost", "Devices/0/Name": "first", "Devices/1/Name": "second"}},
+		{"config/edgex-core-data;go/configuration.toml", readTomlFile, pkg.ConfigProperties{"ServicePort": "48080"}},
+		{"config/device-virtual/application.properties", readPropertiesFile, pkg.ConfigProperties{"application.name": "device-virtual"}},
+		{"config/device-virtual;docker/application.properties", readPropertiesFile, pkg.ConfigProperties{"server.port": "49990"}},
+	}
+	for _, test := range tests {
+		props, err := test.read(filepath.Join(root, test.path))
+		if err != nil {
+			t.Errorf("%s: %v", test.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(props, test.expected) {
+			t.Errorf("%s: bad keys %#v", test.path, props)
+		}
+	}
+
+	// With a profile the merged tree still goes to the base file, the overlay is not written.
+	if err := exportConfig(root, "docker", coreConfig, s); err != nil {
+		t.Fatal(err)
+	}
+	overlay := filepath.Join(root, "pkg/v2/toml/EdgeX_Core_Data/configuration-docker.toml")
+	if _, err := os.Stat(overlay); !os.IsNotExist(err) {
+		t.Errorf("wrote the overlay of the docker profile: %v", err)
+	}
+}
+
+func TestWriteTomlFileTypes(t *testing.T) {
+	root, err := ioutil.TempDir("", "export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	tests := []struct {
+		service  string
+		props    pkg.ConfigProperties
+		expected []string
+	}{
+		{"EdgeX_Core_Data", pkg.ConfigProperties{"Service/Port": "48082", "Service/Host": "48080", "Service/Timeout": "5s"},
+			[]string{"Port = 48082", `Host = "48080"`, `Timeout = "5s"`}},
+		{"Svc", pkg.ConfigProperties{"Port": "48080", "Code": "007", "Offset": "+5", "Ratio": "1.10", "Scale": "1.5", "Enabled": "true", "Name": "True"},
+			[]string{"Port = 48080", `Code = "007"`, `Offset = "+5"`, `Ratio = "1.10"`, "Scale = 1.5", "Enabled = true", `Name = "True"`}},
+	}
+	for _, test := range tests {
+		path := filepath.Join(root, test.service+".toml")
+		if err := writeTomlFile(path, test.service, test.props); err != nil {
+			t.Fatal(err)
+		}
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, line := range test.expected {
+			if !strings.Contains(string(contents), line) {
+				t.Errorf("%s: %q is missing from\n%s", test.service, line, contents)
+			}
+		}
+	}
+
+	err = writeTomlFile(filepath.Join(root, "conflict.toml"), "Svc", pkg.ConfigProperties{"a": "1", "a/b": "2"})
+	if err == nil || !strings.Contains(err.Error(), "a holds a value and the key a/b") {
+		t.Errorf("expected a conflict, got %v", err)
+	}
+}
@@ -18,7 +18,6 @@
 package config
 
 import (
-	"flag"
 	"fmt"
 	"github.com/BurntSushi/toml"
 	"io/ioutil"
@@ -31,11 +30,14 @@ const (
 	configDirEnv    = "EDGEX_CONF_DIR"
 )
 
-var confDir = flag.String("confdir", "", "Specify local configuration directory")
+// ConfDir is the local configuration directory, set by the caller from its command line.
+// EDGEX_CONF_DIR and then ./res are used when it is empty.
+var ConfDir string
 
-func LoadFromFile(configuration interface{}) error {
+// Load the configuration file of a profile, configuration.toml when profile is empty.
+func LoadFromFile(profile string, configuration interface{}) error {
 	path := determinePath()
-	fileName := path + "/" + "configuration.toml"
+	fileName := path + "/" + determineConfigFile(profile)
 
 	contents, err := ioutil.ReadFile(fileName)
 	if err != nil {
@@ -59,9 +61,7 @@ func determineConfigFile(profile string) string {
 }
 
 func determinePath() string {
-	flag.Parse()
-
-	path := *confDir
+	path := ConfDir
 
 	if len(path) == 0 { //No cmd line param passed
 		//Assumption: one service per container means only one var is needed, set accordingly for each deployment.
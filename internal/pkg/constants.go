@@ -45,12 +45,39 @@ type CoreConfig struct {
 	ConsulProtocol               string
 	ConsulHost                   string
 	ConsulPort                   int
+	ConsulToken                  string
+	ConsulTokenFile              string
+	ConsulCACert                 string
+	ConsulClientCert             string
+	ConsulClientKey              string
+	ConsulInsecureSkipVerify     bool
+	StoreType                    string
+	EtcdEndpoints                []string
+	StorePath                    string
 	IsReset                      bool
+	IsSync                       bool
+	IsPrune                      bool
+	IsAtomic                     bool
+	AtomicScope                  string
+	SnapshotPath                 string
+	SnapshotPrefix               string
+	SnapshotLimit                int
+	VariablesFile                string
+	SecretPatterns               []string
+	SecretStore                  string
+	VaultAddress                 string
+	VaultToken                   string
+	VaultMount                   string
+	VaultPath                    string
 	FailLimit                    int
 	FailWaitTime                 int
+	MaxWaitTime                  int
+	ReadyTimeout                 int
 	AcceptablePropertyExtensions []string
 	YamlExtensions               []string
 	TomlExtensions               []string
+	JsonExtensions               []string
+	HclExtensions                []string
 }
 
 var CoreConfiguration  = CoreConfig{}    // Needs to be initialized before use
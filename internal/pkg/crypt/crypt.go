@@ -0,0 +1,110 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+// Package crypt decrypts the values and files of the configuration encrypted with age,
+// in the style of sops. A value is encrypted as ENC[age,<base64 of the age ciphertext>] while
+// a whole file is encrypted as is, armored or not.
+package crypt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+const (
+	valuePrefix = "ENC[age,"
+	valueSuffix = "]"
+
+	// The first line of an age file, binary or armored.
+	fileHeader  = "age-encryption.org/"
+	armorHeader = "-----BEGIN AGE ENCRYPTED FILE-----"
+)
+
+// IsEncryptedValue tells whether a value is an ENC[age,...] value.
+func IsEncryptedValue(value string) bool {
+	return strings.HasPrefix(value, valuePrefix) && strings.HasSuffix(value, valueSuffix)
+}
+
+// IsEncryptedFile tells whether the contents of a file are encrypted with age.
+func IsEncryptedFile(contents []byte) bool {
+	trimmed := bytes.TrimSpace(contents)
+	return bytes.HasPrefix(trimmed, []byte(fileHeader)) || bytes.HasPrefix(trimmed, []byte(armorHeader))
+}
+
+// EncryptValue encrypts a value to the recipients as an ENC[age,...] value.
+func EncryptValue(value string, recipients ...age.Recipient) (string, error) {
+	var b bytes.Buffer
+	w, err := age.Encrypt(&b, recipients...)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.WriteString(w, value); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return valuePrefix + base64.StdEncoding.EncodeToString(b.Bytes()) + valueSuffix, nil
+}
+
+// Decrypter decrypts values and files with the identities of an age key file.
+type Decrypter struct {
+	identities []age.Identity
+}
+
+// NewDecrypter reads the identities of an age key file, as written by age-keygen.
+func NewDecrypter(keys io.Reader) (*Decrypter, error) {
+	identities, err := age.ParseIdentities(keys)
+	if err != nil {
+		return nil, err
+	}
+	return &Decrypter{identities: identities}, nil
+}
+
+// DecryptValue decrypts an ENC[age,...] value.
+func (d *Decrypter) DecryptValue(value string) (string, error) {
+	if !IsEncryptedValue(value) {
+		return "", errors.New("not an ENC[age,...] value")
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(strings.TrimSuffix(strings.TrimPrefix(value, valuePrefix), valueSuffix))
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := d.decrypt(bytes.NewReader(ciphertext))
+	return string(plaintext), err
+}
+
+// DecryptFile decrypts the contents of a file encrypted with age.
+func (d *Decrypter) DecryptFile(contents []byte) ([]byte, error) {
+	trimmed := bytes.TrimSpace(contents)
+	if bytes.HasPrefix(trimmed, []byte(armorHeader)) {
+		return d.decrypt(armor.NewReader(bytes.NewReader(trimmed)))
+	}
+	return d.decrypt(bytes.NewReader(contents))
+}
+
+func (d *Decrypter) decrypt(ciphertext io.Reader) ([]byte, error) {
+	r, err := age.Decrypt(ciphertext, d.identities...)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(r)
+}
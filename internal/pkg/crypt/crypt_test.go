@@ -0,0 +1,108 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package crypt
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+func newTestDecrypter(t *testing.T) (*Decrypter, *age.X25519Identity) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := NewDecrypter(strings.NewReader("# created: for the tests\n" + identity.String() + "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d, identity
+}
+
+func TestDecryptValue(t *testing.T) {
+	d, identity := newTestDecrypter(t)
+
+	value, err := EncryptValue("p4ss", identity.Recipient())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncryptedValue(value) || IsEncryptedValue("p4ss") {
+		t.Fatalf("unexpected encrypted value %s", value)
+	}
+	plaintext, err := d.DecryptValue(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plaintext != "p4ss" {
+		t.Errorf("unexpected plaintext %q", plaintext)
+	}
+
+	// A value encrypted to another key cannot be decrypted.
+	other, _ := age.GenerateX25519Identity()
+	value, err = EncryptValue("p4ss", other.Recipient())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.DecryptValue(value); err == nil {
+		t.Error("expected an error for a value of another key")
+	}
+	if _, err := d.DecryptValue("ENC[age,not base64]"); err == nil {
+		t.Error("expected an error for a malformed value")
+	}
+}
+
+func TestDecryptFile(t *testing.T) {
+	d, identity := newTestDecrypter(t)
+	contents := []byte("[Database]\nPassword = 'p4ss'\n")
+
+	for _, armored := range []bool{false, true} {
+		var b bytes.Buffer
+		var out io.WriteCloser = nopCloser{&b}
+		if armored {
+			out = armor.NewWriter(&b)
+		}
+		w, err := age.Encrypt(out, identity.Recipient())
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(contents)
+		w.Close()
+		out.Close()
+
+		if !IsEncryptedFile(b.Bytes()) {
+			t.Fatalf("armored %v: not detected as encrypted", armored)
+		}
+		plaintext, err := d.DecryptFile(b.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(plaintext, contents) {
+			t.Errorf("armored %v: unexpected plaintext %q", armored, plaintext)
+		}
+	}
+	if IsEncryptedFile(contents) {
+		t.Error("plain file detected as encrypted")
+	}
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
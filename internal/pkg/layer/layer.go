@@ -0,0 +1,48 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+// Package layer lists and merges the layered configuration files of a V2 service: the base file,
+// then the file of every profile, each deep-merged over the ones before.
+package layer
+
+import "strings"
+
+// Base is the configuration file every profile is layered over.
+const Base = "configuration.toml"
+
+// Files returns the configuration files layered for a comma-separated list of profiles, in order:
+// the base file first, then configuration-<profile>.toml for every profile.
+func Files(profile string) []string {
+	files := []string{Base}
+	for _, p := range strings.Split(profile, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			files = append(files, "configuration-"+p+".toml")
+		}
+	}
+	return files
+}
+
+// Merge merges src over dst: tables are merged key by key, any other value, lists included,
+// replaces the one in dst.
+func Merge(dst, src map[string]interface{}) {
+	for k, v := range src {
+		if table, ok := v.(map[string]interface{}); ok {
+			if below, ok := dst[k].(map[string]interface{}); ok {
+				Merge(below, table)
+				continue
+			}
+		}
+		dst[k] = v
+	}
+}
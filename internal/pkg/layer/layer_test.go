@@ -0,0 +1,50 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package layer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFiles(t *testing.T) {
+	for profile, expected := range map[string][]string{
+		"":               {"configuration.toml"},
+		"docker":         {"configuration.toml", "configuration-docker.toml"},
+		"docker, arm64,": {"configuration.toml", "configuration-docker.toml", "configuration-arm64.toml"},
+	} {
+		if files := Files(profile); !reflect.DeepEqual(files, expected) {
+			t.Errorf("%q: expected %v, got %v", profile, expected, files)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	dst := map[string]interface{}{
+		"Service": map[string]interface{}{"Host": "localhost", "Port": int64(48080)},
+		"Devices": []interface{}{"first", "second"},
+	}
+	Merge(dst, map[string]interface{}{
+		"Service": map[string]interface{}{"Host": "edgex-core-data"},
+		"Devices": []interface{}{"third"},
+	})
+
+	expected := map[string]interface{}{
+		"Service": map[string]interface{}{"Host": "edgex-core-data", "Port": int64(48080)},
+		"Devices": []interface{}{"third"},
+	}
+	if !reflect.DeepEqual(dst, expected) {
+		t.Errorf("expected %v, got %v", expected, dst)
+	}
+}
@@ -0,0 +1,82 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package secret
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/edgexfoundry/core-config-seed-go/pkg/v2/types"
+)
+
+// Redacted replaces the value of a secret key in every output.
+const Redacted = "<redacted>"
+
+// Matcher tells which keys hold secrets: the fields tagged secret:"true" in the types of the V2
+// services, the keys matching one of its patterns and the keys added to it. The zero Matcher only
+// knows the tagged fields.
+type Matcher struct {
+	patterns []*regexp.Regexp
+	keys     map[string]bool
+}
+
+// NewMatcher compiles the regular expressions a secret key must match.
+func NewMatcher(patterns []string) (*Matcher, error) {
+	m := &Matcher{}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		m.patterns = append(m.patterns, re)
+	}
+	return m, nil
+}
+
+// IsSecret tells whether a key holds a secret. The key is either relative to its service, e.g.
+// Database/Password, or a full key such as config/EdgeX_Core_Data/Database/Password.
+func (m *Matcher) IsSecret(key string) bool {
+	if m.keys[key] {
+		return true
+	}
+	for _, re := range m.patterns {
+		if re.MatchString(key) {
+			return true
+		}
+	}
+	for _, st := range types.Services() {
+		for _, s := range st.Secrets {
+			if key == s || key == st.Name+"/"+s || strings.HasSuffix(key, "/"+st.Name+"/"+s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Add marks a full key as a secret, e.g. because its value was encrypted.
+func (m *Matcher) Add(key string) {
+	if m.keys == nil {
+		m.keys = map[string]bool{}
+	}
+	m.keys[key] = true
+}
+
+// Redact returns the value of a key for output, Redacted when the key holds a secret.
+func (m *Matcher) Redact(key string, value string) string {
+	if m.IsSecret(key) {
+		return Redacted
+	}
+	return value
+}
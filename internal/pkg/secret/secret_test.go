@@ -0,0 +1,143 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package secret
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestMatcher(t *testing.T) {
+	m, err := NewMatcher([]string{"(?i)password", "Token$"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, expected := range map[string]bool{
+		"config/EdgeX_Core_Data/Database/Password":  true,
+		"EdgeX_Core_Data/Database/Password":         true,
+		"Database/Password":                         true,
+		"config/edgex-core-data;go/MongoDBPassword": true,
+		"config/device-virtual/ConsulToken":         true,
+		"config/EdgeX_Core_Data/Database/Host":      false,
+		"config/device-virtual/TokenCount":          false,
+	} {
+		if m.IsSecret(key) != expected {
+			t.Errorf("%s: expected %v", key, expected)
+		}
+	}
+
+	// Without patterns only the tagged fields of the V2 types are secret.
+	zero := &Matcher{}
+	if !zero.IsSecret("config/EdgeX_Core_Data/Database/Password") || zero.IsSecret("config/edgex-core-data;go/MongoDBPassword") {
+		t.Error("unexpected secrets of the zero Matcher")
+	}
+	if zero.Redact("Database/Password", "p4ss") != Redacted || zero.Redact("Database/Host", "mongo") != "mongo" {
+		t.Error("unexpected redaction")
+	}
+
+	zero.Add("config/svc/ApiKey")
+	if !zero.IsSecret("config/svc/ApiKey") || zero.IsSecret("config/other/ApiKey") {
+		t.Error("unexpected secrets after Add")
+	}
+
+	if _, err := NewMatcher([]string{"("}); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
+
+// Stand-in for a Vault server in dev mode with a KV v2 engine mounted at secret.
+func newVaultStub(token string) (*httptest.Server, map[string]map[string]string) {
+	var mutex sync.Mutex
+	data := map[string]map[string]string{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != token {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+		if !strings.HasPrefix(r.URL.Path, "/v1/secret/data/") {
+			http.NotFound(w, r)
+			return
+		}
+		path := strings.TrimPrefix(r.URL.Path, "/v1/secret/data/")
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		switch r.Method {
+		case http.MethodPost, http.MethodPut:
+			var req struct {
+				Data map[string]string `json:"data"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			data[path] = req.Data
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"version": 1}})
+		case http.MethodGet:
+			secrets, ok := data[path]
+			if !ok {
+				http.Error(w, `{"errors":[]}`, http.StatusNotFound)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"data": secrets}})
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})), data
+}
+
+func TestVaultStore(t *testing.T) {
+	stub, data := newVaultStub("root")
+	defer stub.Close()
+
+	v := NewVaultStore(stub.URL+"/", "root", "secret", nil)
+	if err := v.Put("edgex/EdgeX_Core_Data", map[string]string{"Database/Password": "p4ss"}); err != nil {
+		t.Fatal(err)
+	}
+	if data["edgex/EdgeX_Core_Data"]["Database/Password"] != "p4ss" {
+		t.Errorf("unexpected data %v", data)
+	}
+
+	secrets, err := v.Get("edgex/EdgeX_Core_Data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secrets["Database/Password"] != "p4ss" {
+		t.Errorf("unexpected secrets %v", secrets)
+	}
+	if ref := v.Reference("edgex/EdgeX_Core_Data", "Database/Password"); ref != "vault:secret/edgex/EdgeX_Core_Data#Database/Password" {
+		t.Errorf("unexpected reference %s", ref)
+	}
+	if mount, path, key, ok := ParseReference("vault:secret/edgex/EdgeX_Core_Data#Database/Password"); !ok ||
+		mount != "secret" || path != "edgex/EdgeX_Core_Data" || key != "Database/Password" {
+		t.Errorf("unexpected parts %s %s %s", mount, path, key)
+	}
+	for _, value := range []string{"p4ss", "vault:secret#key", "vault:secret/path#", "vault:/path#key"} {
+		if _, _, _, ok := ParseReference(value); ok {
+			t.Errorf("%s is not a reference", value)
+		}
+	}
+
+	if _, err := v.Get("edgex/missing"); err == nil {
+		t.Error("expected an error for a missing path")
+	}
+	denied := NewVaultStore(stub.URL, "wrong", "secret", nil)
+	if err := denied.Put("edgex/EdgeX_Core_Data", nil); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("expected permission denied, got %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package secret
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// Vault is the name of the Vault secret store in the configuration.
+const Vault = "vault"
+
+// Prefix of the references to Vault put to the K/V store in place of a secret.
+const referencePrefix = "vault:"
+
+// VaultStore writes secrets to a KV version 2 secrets engine of Vault.
+type VaultStore struct {
+	address string
+	token   string
+	mount   string
+	client  *http.Client
+}
+
+// NewVaultStore returns a store writing to the KV v2 engine mounted at mount, e.g. secret,
+// of the Vault server at address, e.g. http://localhost:8200.
+func NewVaultStore(address string, token string, mount string, client *http.Client) *VaultStore {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &VaultStore{address: strings.TrimSuffix(address, "/"), token: token, mount: mount, client: client}
+}
+
+// Reference returns the value put to the K/V store in place of the secret of a key held at path,
+// e.g. vault:secret/edgex/EdgeX_Core_Data#Database/Password.
+func (v *VaultStore) Reference(path string, key string) string {
+	return referencePrefix + v.mount + "/" + path + "#" + key
+}
+
+// IsReference tells whether a value is a reference to a secret made by Reference.
+func IsReference(value string) bool {
+	return strings.HasPrefix(value, referencePrefix)
+}
+
+// ParseReference splits a reference made by Reference into the mount, the path and the key of its secret.
+func ParseReference(value string) (mount string, path string, key string, ok bool) {
+	if !IsReference(value) {
+		return "", "", "", false
+	}
+	ref := strings.TrimPrefix(value, referencePrefix)
+	i, j := strings.Index(ref, "/"), strings.LastIndex(ref, "#")
+	if i <= 0 || j <= i+1 || j == len(ref)-1 {
+		return "", "", "", false
+	}
+	return ref[:i], ref[i+1 : j], ref[j+1:], true
+}
+
+// Put writes the secrets of path as a new version, replacing every key of the previous one.
+func (v *VaultStore) Put(path string, secrets map[string]string) error {
+	body, err := json.Marshal(map[string]interface{}{"data": secrets})
+	if err != nil {
+		return err
+	}
+	_, err = v.do(http.MethodPost, path, bytes.NewReader(body))
+	return err
+}
+
+// Get reads the secrets of the latest version of path.
+func (v *VaultStore) Get(path string) (map[string]string, error) {
+	body, err := v.do(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Data struct {
+			Data map[string]string `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data.Data, nil
+}
+
+func (v *VaultStore) do(method string, path string, body io.Reader) ([]byte, error) {
+	url := v.address + "/v1/" + v.mount + "/data/" + path
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("vault %s %s: %s: %s", method, url, resp.Status, strings.TrimSpace(string(b)))
+	}
+	return b, nil
+}
@@ -0,0 +1,129 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+// Hook the functions in the other packages for the tests.
+var (
+	consulDelete     = (*consulapi.KV).Delete
+	consulDeleteTree = (*consulapi.KV).DeleteTree
+	consulPut        = (*consulapi.KV).Put
+	consulGet        = (*consulapi.KV).Get
+	consulKeys       = (*consulapi.KV).Keys
+	consulList       = (*consulapi.KV).List
+	consulTxn        = (*consulapi.KV).Txn
+)
+
+type consulStore struct {
+	kv *consulapi.KV
+}
+
+// NewConsulStore returns a ConfigStore backed by the Consul K/V store.
+// A Consul transaction holds at most 64 operations.
+func NewConsulStore(kv *consulapi.KV) ConfigStore {
+	return &consulStore{kv: kv}
+}
+
+func (s *consulStore) Put(key string, value []byte) error {
+	_, err := consulPut(s.kv, &consulapi.KVPair{Key: key, Value: value}, nil)
+	return err
+}
+
+func (s *consulStore) Get(key string) ([]byte, bool, error) {
+	pair, _, err := consulGet(s.kv, key, nil)
+	if err != nil || pair == nil {
+		return nil, false, err
+	}
+	return pair.Value, true, nil
+}
+
+func (s *consulStore) Keys(prefix string) ([]string, error) {
+	keys, _, err := consulKeys(s.kv, prefix, "", nil)
+	return keys, err
+}
+
+func (s *consulStore) List(prefix string) (map[string][]byte, error) {
+	pairs, _, err := consulList(s.kv, prefix, nil)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string][]byte{}
+	for _, pair := range pairs {
+		values[pair.Key] = pair.Value
+	}
+	return values, nil
+}
+
+// WatchPrefix runs a blocking query, which Consul answers once the index of the prefix passes
+// the given one or after its wait time, five minutes by default.
+func (s *consulStore) WatchPrefix(ctx context.Context, prefix string, index uint64) (map[string][]byte, uint64, error) {
+	opts := (&consulapi.QueryOptions{WaitIndex: index}).WithContext(ctx)
+	pairs, meta, err := consulList(s.kv, prefix, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	values := map[string][]byte{}
+	for _, pair := range pairs {
+		values[pair.Key] = pair.Value
+	}
+	return values, meta.LastIndex, nil
+}
+
+func (s *consulStore) Delete(key string) error {
+	_, err := consulDelete(s.kv, key, nil)
+	return err
+}
+
+func (s *consulStore) DeleteTree(prefix string) error {
+	_, err := consulDeleteTree(s.kv, prefix, nil)
+	return err
+}
+
+func (s *consulStore) Txn(ops []TxnOp) error {
+	txn := consulapi.KVTxnOps{}
+	for _, op := range ops {
+		verb := consulapi.KVSet
+		switch op.Verb {
+		case TxnDelete:
+			verb = consulapi.KVDelete
+		case TxnDeleteTree:
+			verb = consulapi.KVDeleteTree
+		}
+		txn = append(txn, &consulapi.KVTxnOp{Verb: verb, Key: op.Key, Value: op.Value})
+	}
+
+	ok, resp, _, err := consulTxn(s.kv, txn, nil)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		// Consul rolled back every operation, report the ones which failed.
+		whats := []string{}
+		if resp != nil {
+			for _, e := range resp.Errors {
+				whats = append(whats, fmt.Sprintf("%s: %s", ops[e.OpIndex].Key, e.What))
+			}
+		}
+		return fmt.Errorf("transaction rolled back: %s", strings.Join(whats, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package store
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+func TestConsulStoreWatchPrefix(t *testing.T) {
+	agent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/kv/config/" || r.URL.Query().Get("index") != "7" {
+			http.Error(w, "unexpected query "+r.URL.String(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("X-Consul-Index", "8")
+		fmt.Fprintf(w, `[{"Key": "config/Svc/Port", "Value": "%s"}]`, base64.StdEncoding.EncodeToString([]byte("48080")))
+	}))
+	defer agent.Close()
+
+	client, err := consulapi.NewClient(&consulapi.Config{Address: strings.TrimPrefix(agent.URL, "http://")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	watcher, ok := NewConsulStore(client.KV()).(Watcher)
+	if !ok {
+		t.Fatal("the Consul store is not a Watcher")
+	}
+
+	values, index, err := watcher.WatchPrefix(context.Background(), "config/", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index != 8 || string(values["config/Svc/Port"]) != "48080" {
+		t.Errorf("unexpected values %v at index %d", values, index)
+	}
+}
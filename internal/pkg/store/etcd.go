@@ -0,0 +1,138 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// Hook the functions of the etcd client for the tests.
+var (
+	etcdPut    = clientv3.KV.Put
+	etcdGet    = clientv3.KV.Get
+	etcdDelete = clientv3.KV.Delete
+	etcdTxn    = func(kv clientv3.KV, ctx context.Context, ops ...clientv3.Op) (*clientv3.TxnResponse, error) {
+		return kv.Txn(ctx).Then(ops...).Commit()
+	}
+)
+
+type etcdStore struct {
+	client  clientv3.KV
+	timeout time.Duration
+}
+
+// NewEtcdStore returns a ConfigStore backed by an etcd v3 cluster.
+// Every request is cancelled after timeout. etcd limits a transaction to 128 operations by default.
+func NewEtcdStore(endpoints []string, timeout time.Duration) (ConfigStore, error) {
+	client, err := clientv3.New(clientv3.Config{Endpoints: endpoints, DialTimeout: timeout})
+	if err != nil {
+		return nil, err
+	}
+	return &etcdStore{client: client, timeout: timeout}, nil
+}
+
+func (s *etcdStore) Put(key string, value []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	_, err := etcdPut(s.client, ctx, key, string(value))
+	return err
+}
+
+func (s *etcdStore) Get(key string) ([]byte, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	resp, err := etcdGet(s.client, ctx, key)
+	if err != nil || len(resp.Kvs) == 0 {
+		return nil, false, err
+	}
+	return resp.Kvs[0].Value, true, nil
+}
+
+func (s *etcdStore) Keys(prefix string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	resp, err := etcdGet(s.client, ctx, prefix, clientv3.WithPrefix(), clientv3.WithKeysOnly())
+	if err != nil {
+		return nil, err
+	}
+	keys := []string{}
+	for _, kv := range resp.Kvs {
+		keys = append(keys, string(kv.Key))
+	}
+	return keys, nil
+}
+
+func (s *etcdStore) List(prefix string) (map[string][]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	resp, err := etcdGet(s.client, ctx, prefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	values := map[string][]byte{}
+	for _, kv := range resp.Kvs {
+		values[string(kv.Key)] = kv.Value
+	}
+	return values, nil
+}
+
+func (s *etcdStore) Delete(key string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	_, err := etcdDelete(s.client, ctx, key)
+	return err
+}
+
+func (s *etcdStore) DeleteTree(prefix string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	_, err := etcdDelete(s.client, ctx, prefix, clientv3.WithPrefix())
+	return err
+}
+
+func (s *etcdStore) Txn(ops []TxnOp) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	txn := []clientv3.Op{}
+	for _, op := range ops {
+		switch op.Verb {
+		case TxnDelete:
+			txn = append(txn, clientv3.OpDelete(op.Key))
+		case TxnDeleteTree:
+			txn = append(txn, clientv3.OpDelete(op.Key, clientv3.WithPrefix()))
+		default:
+			txn = append(txn, clientv3.OpPut(op.Key, string(op.Value)))
+		}
+	}
+
+	resp, err := etcdTxn(s.client, ctx, txn...)
+	if err != nil {
+		return err
+	}
+	if !resp.Succeeded {
+		return errors.New("etcd transaction was not applied")
+	}
+	return nil
+}
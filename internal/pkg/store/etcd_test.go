@@ -0,0 +1,159 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package store
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// Keys and values of a fake etcd, the hooks reading the range of their operations like etcd does.
+type etcdFake map[string]string
+
+// The keys of the fake in the range of an operation, sorted.
+func (f etcdFake) inRange(op clientv3.Op) []string {
+	key, end := string(op.KeyBytes()), string(op.RangeBytes())
+	keys := []string{}
+	for k := range f {
+		if k == key || (end != "" && k >= key && k < end) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (f etcdFake) apply(op clientv3.Op) {
+	if op.IsPut() {
+		f[string(op.KeyBytes())] = string(op.ValueBytes())
+		return
+	}
+	for _, k := range f.inRange(op) {
+		delete(f, k)
+	}
+}
+
+func useEtcdFake(t *testing.T, f etcdFake, failTxn bool) func() {
+	put, get, del, txn := etcdPut, etcdGet, etcdDelete, etcdTxn
+	etcdPut = func(kv clientv3.KV, ctx context.Context, key, val string, opts ...clientv3.OpOption) (*clientv3.PutResponse, error) {
+		f.apply(clientv3.OpPut(key, val, opts...))
+		return &clientv3.PutResponse{}, nil
+	}
+	etcdGet = func(kv clientv3.KV, ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+		op := clientv3.OpGet(key, opts...)
+		resp := &clientv3.GetResponse{}
+		for _, k := range f.inRange(op) {
+			kv := &mvccpb.KeyValue{Key: []byte(k)}
+			if !op.IsKeysOnly() {
+				kv.Value = []byte(f[k])
+			}
+			resp.Kvs = append(resp.Kvs, kv)
+		}
+		return resp, nil
+	}
+	etcdDelete = func(kv clientv3.KV, ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.DeleteResponse, error) {
+		f.apply(clientv3.OpDelete(key, opts...))
+		return &clientv3.DeleteResponse{}, nil
+	}
+	etcdTxn = func(kv clientv3.KV, ctx context.Context, ops ...clientv3.Op) (*clientv3.TxnResponse, error) {
+		if failTxn {
+			return nil, errors.New("etcdserver: too many operations in txn request")
+		}
+		for _, op := range ops {
+			f.apply(op)
+		}
+		return &clientv3.TxnResponse{Succeeded: true}, nil
+	}
+	return func() { etcdPut, etcdGet, etcdDelete, etcdTxn = put, get, del, txn }
+}
+
+func TestEtcdStore(t *testing.T) {
+	f := etcdFake{"other/Port": "1"}
+	defer useEtcdFake(t, f, false)()
+	s := &etcdStore{timeout: time.Second}
+
+	for k, v := range map[string]string{"config/Svc/Host": "localhost", "config/Svc/Port": "48080", "config/Other/Port": "48081"} {
+		if err := s.Put(k, []byte(v)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if value, ok, err := s.Get("config/Svc/Port"); err != nil || !ok || string(value) != "48080" {
+		t.Errorf("unexpected value %q, %v, %v", value, ok, err)
+	}
+	if _, ok, err := s.Get("config/Svc/Missing"); err != nil || ok {
+		t.Errorf("expected a missing key, got %v, %v", ok, err)
+	}
+
+	keys, err := s.Keys("config/Svc/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(keys, []string{"config/Svc/Host", "config/Svc/Port"}) {
+		t.Errorf("unexpected keys %v", keys)
+	}
+	values, err := s.List("config/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string][]byte{"config/Other/Port": []byte("48081"), "config/Svc/Host": []byte("localhost"), "config/Svc/Port": []byte("48080")}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("unexpected values %v", values)
+	}
+
+	err = s.Txn([]TxnOp{
+		{Verb: TxnSet, Key: "config/Svc/Port", Value: []byte("48090")},
+		{Verb: TxnDelete, Key: "config/Svc/Host"},
+		{Verb: TxnDeleteTree, Key: "config/Other/"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(f, etcdFake{"config/Svc/Port": "48090", "other/Port": "1"}) {
+		t.Errorf("unexpected data after the transaction %v", f)
+	}
+
+	if err := s.Delete("config/Svc/Port"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := f["config/Svc/Port"]; ok {
+		t.Error("config/Svc/Port not deleted")
+	}
+
+	if err := s.DeleteTree("config/"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(f, etcdFake{"other/Port": "1"}) {
+		t.Errorf("unexpected data after the delete %v", f)
+	}
+}
+
+func TestEtcdStoreTxnFailure(t *testing.T) {
+	f := etcdFake{"config/Svc/Port": "48080"}
+	defer useEtcdFake(t, f, true)()
+	s := &etcdStore{timeout: time.Second}
+
+	if err := s.Txn([]TxnOp{{Verb: TxnSet, Key: "config/Svc/Port", Value: []byte("48090")}}); err == nil {
+		t.Error("expected the transaction to fail")
+	}
+	if f["config/Svc/Port"] != "48080" {
+		t.Error("a failed transaction must not change anything")
+	}
+}
@@ -0,0 +1,240 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package store
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Prefix of the temporary files values are written to before they replace the file of their key.
+// Keys with a segment starting with it are refused, so that temporary files are never taken for keys.
+const tempPrefix = ".tmp-"
+
+type fileStore struct {
+	root string
+}
+
+// NewFileStore returns a ConfigStore which keeps every key as a file under the root directory,
+// e.g. the key "config/edgex-core-data/ServicePort" is the file <root>/config/edgex-core-data/ServicePort.
+func NewFileStore(root string) (ConfigStore, error) {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, err
+	}
+	return &fileStore{root: root}, nil
+}
+
+// Map a key to its file, refusing keys which would escape the root directory.
+func (s *fileStore) path(key string) (string, error) {
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." || strings.HasPrefix(segment, tempPrefix) {
+			return "", fmt.Errorf("invalid key for the file store: %q", key)
+		}
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
+
+func (s *fileStore) Put(key string, value []byte) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that readers never see a partial value.
+	tmp, err := ioutil.TempFile(filepath.Dir(path), tempPrefix)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(value)
+	if err == nil {
+		err = tmp.Chmod(0644)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *fileStore) Get(key string) ([]byte, bool, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, false, err
+	}
+
+	value, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+func (s *fileStore) Keys(prefix string) ([]string, error) {
+	keys := []string{}
+	err := filepath.Walk(s.root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || strings.HasPrefix(info.Name(), tempPrefix) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.root, path)
+		if err != nil {
+			return err
+		}
+		if key := filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	return keys, err
+}
+
+func (s *fileStore) List(prefix string) (map[string][]byte, error) {
+	keys, err := s.Keys(prefix)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string][]byte{}
+	for _, key := range keys {
+		value, ok, err := s.Get(key)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			values[key] = value
+		}
+	}
+	return values, nil
+}
+
+func (s *fileStore) Delete(key string) error {
+	return s.delete(key)
+}
+
+func (s *fileStore) DeleteTree(prefix string) error {
+	keys, err := s.Keys(prefix)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := s.delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Remove the file of a key along with the directories it leaves empty.
+func (s *fileStore) delete(key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	for dir := filepath.Dir(path); dir != filepath.Clean(s.root); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+// Txn applies the operations in order. When one of them fails, the keys touched so far are
+// restored to the values they had before the transaction; the keys which could not be restored
+// are reported along with the failure.
+func (s *fileStore) Txn(ops []TxnOp) error {
+	type saved struct {
+		value  []byte
+		exists bool
+	}
+	before := map[string]saved{}
+	remember := func(key string) error {
+		if _, ok := before[key]; ok {
+			return nil
+		}
+		value, ok, err := s.Get(key)
+		if err != nil {
+			return err
+		}
+		before[key] = saved{value: value, exists: ok}
+		return nil
+	}
+
+	apply := func(op TxnOp) error {
+		switch op.Verb {
+		case TxnDelete:
+			if err := remember(op.Key); err != nil {
+				return err
+			}
+			return s.delete(op.Key)
+		case TxnDeleteTree:
+			keys, err := s.Keys(op.Key)
+			if err != nil {
+				return err
+			}
+			for _, key := range keys {
+				if err := remember(key); err != nil {
+					return err
+				}
+			}
+			return s.DeleteTree(op.Key)
+		default:
+			if err := remember(op.Key); err != nil {
+				return err
+			}
+			return s.Put(op.Key, op.Value)
+		}
+	}
+
+	for _, op := range ops {
+		if err := apply(op); err != nil {
+			failed := []string{}
+			for key, v := range before {
+				var restoreErr error
+				if v.exists {
+					restoreErr = s.Put(key, v.value)
+				} else {
+					restoreErr = s.delete(key)
+				}
+				if restoreErr != nil {
+					failed = append(failed, restoreErr.Error())
+				}
+			}
+			if len(failed) != 0 {
+				sort.Strings(failed)
+				return fmt.Errorf("%v, and the rollback failed: %s", err, strings.Join(failed, "; "))
+			}
+			return err
+		}
+	}
+	return nil
+}
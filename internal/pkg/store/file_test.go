@@ -0,0 +1,134 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func testFileStore(t *testing.T) (ConfigStore, func()) {
+	root, err := ioutil.TempDir("", "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewFileStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, func() { os.RemoveAll(root) }
+}
+
+func TestFileStorePutGetKeys(t *testing.T) {
+	s, cleanup := testFileStore(t)
+	defer cleanup()
+
+	for k, v := range map[string]string{
+		"config/edgex-core-data;docker/ServicePort": "48080",
+		"config/EdgeX_Core_Data/Service/Port":       "48080",
+		"other/Key":                                 "value",
+	} {
+		if err := s.Put(k, []byte(v)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	value, ok, err := s.Get("config/EdgeX_Core_Data/Service/Port")
+	if err != nil || !ok || string(value) != "48080" {
+		t.Fatalf("bad get: %q %v %v", value, ok, err)
+	}
+	if _, ok, err := s.Get("config/missing"); ok || err != nil {
+		t.Fatalf("expected a missing key, got %v %v", ok, err)
+	}
+
+	keys, err := s.Keys("config/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(keys)
+	expected := []string{"config/EdgeX_Core_Data/Service/Port", "config/edgex-core-data;docker/ServicePort"}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Fatalf("bad keys: %#v", keys)
+	}
+
+	if err := s.Delete("config/EdgeX_Core_Data/Service/Port"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := s.Get("config/EdgeX_Core_Data/Service/Port"); ok {
+		t.Error("config/EdgeX_Core_Data/Service/Port not deleted")
+	}
+	if err := s.Delete("config/EdgeX_Core_Data/Service/Port"); err != nil {
+		t.Errorf("deleting a missing key: %v", err)
+	}
+
+	if err := s.DeleteTree("config/"); err != nil {
+		t.Fatal(err)
+	}
+	if keys, _ := s.Keys(""); !reflect.DeepEqual(keys, []string{"other/Key"}) {
+		t.Fatalf("bad keys after delete: %#v", keys)
+	}
+
+	if err := s.Put("../escape", []byte("x")); err == nil {
+		t.Fatal("expected an invalid key error")
+	}
+}
+
+// Keys ending in .tmp are keys like any other, the temporary files being named apart.
+func TestFileStoreTempFiles(t *testing.T) {
+	s, cleanup := testFileStore(t)
+	defer cleanup()
+
+	for k, v := range map[string]string{"config/Svc/backup.tmp": "1", "config/Svc/Port": "48080"} {
+		if err := s.Put(k, []byte(v)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	values, err := s.List("config/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string][]byte{"config/Svc/backup.tmp": []byte("1"), "config/Svc/Port": []byte("48080")}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("unexpected values %v", values)
+	}
+	if err := s.Put("config/Svc/"+tempPrefix+"1", []byte("x")); err == nil {
+		t.Error("expected the name of a temporary file to be refused as a key")
+	}
+}
+
+func TestFileStoreTxnRollback(t *testing.T) {
+	s, cleanup := testFileStore(t)
+	defer cleanup()
+
+	s.Put("config/a/Host", []byte("localhost"))
+
+	err := s.Txn([]TxnOp{
+		{Verb: TxnSet, Key: "config/a/Host", Value: []byte("edgex-core-data")},
+		{Verb: TxnSet, Key: "config/a/Port", Value: []byte("48080")},
+		{Verb: TxnSet, Key: "config/../../escape", Value: []byte("x")},
+	})
+	if err == nil {
+		t.Fatal("expected the transaction to fail")
+	}
+
+	if value, _, _ := s.Get("config/a/Host"); string(value) != "localhost" {
+		t.Errorf("Host not restored: %q", value)
+	}
+	if _, ok, _ := s.Get("config/a/Port"); ok {
+		t.Error("Port not removed")
+	}
+}
@@ -0,0 +1,70 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+// Package store provides the key/value backends the configuration is seeded into.
+package store
+
+import "context"
+
+// Names of the supported backends, as used by the StoreType setting.
+const (
+	Consul = "consul"
+	Etcd   = "etcd"
+	File   = "file"
+)
+
+// TxnVerb is the kind of operation in a transaction.
+type TxnVerb string
+
+const (
+	// TxnSet writes the value of a key.
+	TxnSet TxnVerb = "set"
+	// TxnDelete removes a single key.
+	TxnDelete TxnVerb = "delete"
+	// TxnDeleteTree removes every key starting with the given prefix.
+	TxnDeleteTree TxnVerb = "delete-tree"
+)
+
+// TxnOp is a single operation of a transaction.
+type TxnOp struct {
+	Verb  TxnVerb
+	Key   string
+	Value []byte
+}
+
+// ConfigStore is a key/value store with slash-separated keys, such as the Consul K/V store.
+type ConfigStore interface {
+	// Put writes the value of a key.
+	Put(key string, value []byte) error
+	// Get reads the value of a key. ok is false when the key does not exist.
+	Get(key string) (value []byte, ok bool, err error)
+	// Keys lists every key starting with prefix.
+	Keys(prefix string) ([]string, error)
+	// List reads every key starting with prefix along with its value, in one request.
+	List(prefix string) (map[string][]byte, error)
+	// Delete removes a single key. Deleting a missing key is not an error.
+	Delete(key string) error
+	// DeleteTree removes every key starting with prefix.
+	DeleteTree(prefix string) error
+	// Txn applies all operations or none of them.
+	Txn(ops []TxnOp) error
+}
+
+// Watcher is implemented by the stores which can wait for the keys under a prefix to change,
+// such as Consul with its blocking queries.
+type Watcher interface {
+	// WatchPrefix returns every key under prefix along with the index of the store, once that
+	// index is past the given one. An index of 0 returns at once. It returns early when ctx is done.
+	WatchPrefix(ctx context.Context, prefix string, index uint64) (values map[string][]byte, next uint64, err error)
+}
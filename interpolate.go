@@ -0,0 +1,165 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg"
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg/layer"
+)
+
+// A ${name} reference in a value.
+var referencePattern = regexp.MustCompile(`\$\{([^}]*)\}`)
+
+// Read the variables of the seed: the VariablesFile, then the variables-<profile> file next to it
+// for every profile, each overriding the ones before. Missing files are skipped.
+// Tables are flattened to dotted names, e.g. Hosts.Metadata.
+func readVariables(profile string, coreConfig pkg.CoreConfig) (map[string]string, error) {
+	vars := map[string]string{}
+	if coreConfig.VariablesFile == "" {
+		return vars, nil
+	}
+
+	ext := filepath.Ext(coreConfig.VariablesFile)
+	base := strings.TrimSuffix(coreConfig.VariablesFile, ext)
+	paths := []string{coreConfig.VariablesFile}
+	for _, file := range layer.Files(profile)[1:] {
+		paths = append(paths, base+strings.TrimSuffix(strings.TrimPrefix(file, "configuration"), ".toml")+ext)
+	}
+
+	for _, path := range paths {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+		props, err := readTomlFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not load variables file (%s): %w", path, err)
+		}
+		for k, v := range props {
+			vars[strings.Replace(k, "/", ".", -1)] = v
+		}
+	}
+	return vars, nil
+}
+
+// Resolves the references in the values of one service.
+type interpolator struct {
+	vars     map[string]string
+	props    pkg.ConfigProperties
+	resolved map[string]string
+	// The ids whose value holds the value of a secret key.
+	secret map[string]bool
+	// The ids and names of the references being resolved, to report cycles.
+	ids   []string
+	names []string
+}
+
+// Replace every ${name} in the values of a service. A name is looked up in the variables first,
+// then among the keys of the service, a dotted name such as Clients.Metadata.Host matching the key
+// Clients/Metadata/Host. Values of variables and keys can hold references themselves.
+// Unresolved references and cycles are errors, reported for every key at once. A key whose value
+// holds the value of a secret key, directly or through other keys, becomes a secret.
+func interpolate(service *serviceConfig, vars map[string]string) error {
+	in := &interpolator{vars: vars, props: service.Props, resolved: map[string]string{}, secret: map[string]bool{}}
+
+	keys := []string{}
+	for k := range service.Props {
+		keys = append(keys, k)
+		in.secret["key "+k] = secrets.IsSecret(service.Prefix + k)
+	}
+	sort.Strings(keys)
+
+	problems := []string{}
+	values := pkg.ConfigProperties{}
+	for _, k := range keys {
+		v, err := in.resolve("key "+k, k, service.Props[k])
+		if err != nil {
+			problems = append(problems, k+": "+err.Error())
+			continue
+		}
+		values[k] = v
+	}
+	if len(problems) != 0 {
+		return withExitCode(exitValidation, fmt.Errorf("could not interpolate %s: %s", service.Dir, strings.Join(problems, "; ")))
+	}
+
+	// A key holding the value of a secret key is a secret too.
+	for k, v := range values {
+		service.Props[k] = v
+		if in.secret["key "+k] {
+			secrets.Add(service.Prefix + k)
+		}
+	}
+	return nil
+}
+
+// Find the value a reference points to, along with an id telling variables and keys apart.
+func (in *interpolator) lookup(name string) (string, string, bool) {
+	if v, ok := in.vars[name]; ok {
+		return "variable " + name, v, true
+	}
+	for _, k := range []string{name, strings.Replace(name, ".", "/", -1)} {
+		if v, ok := in.props[k]; ok {
+			return "key " + k, v, true
+		}
+	}
+	return "", "", false
+}
+
+// Expand the references of raw, which is the value of id.
+func (in *interpolator) resolve(id string, name string, raw string) (string, error) {
+	if v, ok := in.resolved[id]; ok {
+		return v, nil
+	}
+	for i, s := range in.ids {
+		if s == id {
+			cycle := append(append([]string{}, in.names[i:]...), name)
+			return "", fmt.Errorf("reference cycle %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	in.ids, in.names = append(in.ids, id), append(in.names, name)
+	defer func() { in.ids, in.names = in.ids[:len(in.ids)-1], in.names[:len(in.names)-1] }()
+
+	var err error
+	value := referencePattern.ReplaceAllStringFunc(raw, func(m string) string {
+		if err != nil {
+			return m
+		}
+		ref := strings.TrimSpace(m[2 : len(m)-1])
+		refID, refRaw, ok := in.lookup(ref)
+		if !ok {
+			err = fmt.Errorf("unresolved reference ${%s}", ref)
+			return m
+		}
+		var v string
+		v, err = in.resolve(refID, ref, refRaw)
+		if in.secret[refID] {
+			in.secret[id] = true
+		}
+		return v
+	})
+	if err != nil {
+		return "", err
+	}
+
+	in.resolved[id] = value
+	return value, nil
+}
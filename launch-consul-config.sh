@@ -25,6 +25,6 @@ rm -rf /consul/data/* && ./docker-entrypoint.sh agent $CONSUL_ARGS | tee /edgex/
 echo "Waiting for $WAIT_FOR_A_WHILE seconds until consul is configured"
 sleep $WAIT_FOR_A_WHILE
 
-./$APP
+./$APP seed
 
 wait
@@ -17,25 +17,30 @@
 package main
 
 import (
-	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
-	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"math/rand"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/edgexfoundry/core-config-seed-go/internal/pkg"
-	"github.com/edgexfoundry/core-config-seed-go/internal/pkg/config"
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg/layer"
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg/store"
 	"github.com/edgexfoundry/core-config-seed-go/pkg/v2/types"
-	"github.com/fatih/structs"
 	"github.com/pelletier/go-toml"
-	"github.com/consulstructure"
 	consulapi "github.com/hashicorp/consul/api"
+	"github.com/hashicorp/hcl"
 	"github.com/magiconair/properties"
 	"gopkg.in/yaml.v2"
 )
@@ -44,63 +49,90 @@ var Version = "master"
 
 const (
 	consulStatusPath = "/v1/agent/self"
-	configDefault    = "configuration.toml"
+	consulLeaderPath = "/v1/status/leader"
+	configDefault    = layer.Base
 )
 
 // Hook the functions in the other packages for the tests.
 var (
 	consulDefaultConfig = consulapi.DefaultConfig
 	consulNewClient     = consulapi.NewClient
-	consulDeleteTree    = (*consulapi.KV).DeleteTree
-	consulPut           = (*consulapi.KV).Put
-	consulKeys          = (*consulapi.KV).Keys
-	httpGet             = http.Get
+	httpDo              = (*http.Client).Do
+	randFloat           = rand.Float64
 )
 
+// Where the readers of the config paths report the files found, the overrides applied and the
+// problems of the values. Commands printing their result to stdout move it to stderr.
+var logOutput io.Writer = os.Stdout
+
 
 var allowOptions = map[string]string{"name": "", "default": ""}
 
 
 // END Consul parse
 func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(exitCode(err))
+	}
+}
 
-	var useConsul bool
-	var useProfile string
-
-	flag.BoolVar(&useConsul, "consul", false, "Indicates the service should use consul.")
-	flag.BoolVar(&useConsul, "c", false, "Indicates the service should use consul.")
-	flag.StringVar(&useProfile, "profile", "", "Specify a profile other than default.")
-	flag.StringVar(&useProfile, "p", "", "Specify a profile other than default.")
-	flag.Parse()
+// Seed the K/V store from the config files. A snapshot of the stored tree is saved first when
+// enabled, every service is read and validated before the tree is reset or written, and the V2
+// services are read back and verified last.
+func seedConfig(profile string, coreConfig pkg.CoreConfig, s store.ConfigStore) error {
+	// Keep the current tree before anything is written.
+	if isSnapshotEnabled(coreConfig) {
+		if _, err := saveSnapshot(coreConfig, s); err != nil {
+			return err
+		}
+	}
 
-	// Configuration data for the config-seed service.
-	coreConfig := &pkg.CoreConfig{}
+	// An atomic reset is applied as a pruning sync, the end state is the same.
+	if coreConfig.IsSync || coreConfig.IsAtomic {
+		if err := syncConfig(profile, coreConfig, s); err != nil {
+			return err
+		}
+		if err := verifyConfig(profile, coreConfig, s); err != nil {
+			return err
+		}
+		printBanner("./res/banner.txt")
+		return nil
+	}
 
-	// Load based on configuration need (docker or go)
-	err := config.LoadFromFile(coreConfig)
+	// read V2 and V1 config files, a bad file leaves the stored tree as it is
+	v2Services, err := readV2ConfigFromPath(profile, coreConfig)
 	if err != nil {
-		logBeforeTermination(err)
-		return
+		return err
 	}
-
-	consulClient, err := getConsulClient(*coreConfig)
+	services, err := readConfigFromPath(profile, coreConfig)
 	if err != nil {
-		fmt.Println(err.Error())
-		return
+		return err
 	}
-
-	kv := consulClient.KV()
+	warnUnmatchedOverrides(readEnvOverrides(), append(v2Services, services...))
 
 	if coreConfig.IsReset {
-		removeStoredConfig(kv)
+		if err := removeStoredConfig(coreConfig, s); err != nil {
+			return err
+		}
 	}
 	// load V2 config files
-	loadV2ConfigFromPath(useProfile, *coreConfig, kv)
+	if err := putV2Services(v2Services, coreConfig, s); err != nil {
+		return err
+	}
 
 	// load V1 config files
-	loadConfigFromPath(*coreConfig, kv)
+	if err := putServices(services, coreConfig, s); err != nil {
+		return err
+	}
+
+	// read the V2 services back and compare them with their files
+	if err := verifyConfig(profile, coreConfig, s); err != nil {
+		return err
+	}
 
 	printBanner("./res/banner.txt")
+	return nil
 }
 
 // Print a banner.
@@ -114,70 +146,84 @@ func printBanner(path string) {
 	fmt.Println(string(b))
 }
 
-func logBeforeTermination(err error) {
-	fmt.Println(err.Error())
-}
-
+// The configuration file of the last of a comma-separated list of profiles.
 func determineConfigFile(profile string) string {
-	if profile == "" {
-		return configDefault
-	}
-	return "configuration-" + profile + ".toml"
+	files := layer.Files(profile)
+	return files[len(files)-1]
 }
 
-func determineCorrectConfigFile(targetConfig string, fileName string) bool {
-
-	if fileName == targetConfig {
-		return true
+// Get handle of Consul client using the URL, ACL token and TLS settings from configuration info.
+// Before getting handle, it waits for the Consul agent to be ready, see waitForConsul.
+func getConsulClient(ctx context.Context, coreConfig pkg.CoreConfig) (*consulapi.Client, error) {
+	consulConfig, err := newConsulConfig(coreConfig)
+	if err != nil {
+		return nil, withExitCode(exitConfigLoad, err)
+	}
+	httpClient, err := newConsulHTTPClient(consulConfig)
+	if err != nil {
+		return nil, withExitCode(exitConfigLoad, err)
 	}
-	return false
-}
 
-// Get handle of Consul client using the URL from configuration info.
-// Before getting handle, it tries to receive a response from a Consul agent by simple health-check.
-func getConsulClient(coreConfig pkg.CoreConfig) (*consulapi.Client, error) {
+	// Check the connection to Consul
+	if err := waitForConsul(ctx, coreConfig, consulConfig, httpClient); err != nil {
+		return nil, withExitCode(exitStoreUnreachable, err)
+	}
 
-	consulUrl := coreConfig.ConsulProtocol + "://" + coreConfig.ConsulHost + ":" + strconv.Itoa(coreConfig.ConsulPort)
+	// Connect to the Consul Agent
+	client, err := consulNewClient(consulConfig)
+	return client, withExitCode(exitStoreUnreachable, err)
+}
 
-	// Check the connection to Consul
-	fails := 0
-	for fails < coreConfig.FailLimit {
-		resp, err := httpGet(consulUrl + consulStatusPath)
-		if err != nil {
-			fmt.Println(err.Error())
-			time.Sleep(time.Second * time.Duration(coreConfig.FailWaitTime))
-			fails++
-			continue
+// Cancel a context on SIGTERM or interrupt, so that waiting for a store can be stopped.
+func newSignalContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+	go func() {
+		defer signal.Stop(signals)
+		select {
+		case <-signals:
+			cancel()
+		case <-ctx.Done():
 		}
+	}()
+	return ctx, cancel
+}
 
-		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-			break
+// Connect to the K/V store selected by StoreType, Consul when it is not set.
+func newConfigStore(ctx context.Context, coreConfig pkg.CoreConfig) (store.ConfigStore, error) {
+	switch coreConfig.StoreType {
+	case "", store.Consul:
+		consulClient, err := getConsulClient(ctx, coreConfig)
+		if err != nil {
+			return nil, err
 		}
+		return store.NewConsulStore(consulClient.KV()), nil
+	case store.Etcd:
+		s, err := store.NewEtcdStore(coreConfig.EtcdEndpoints, time.Second*time.Duration(coreConfig.FailWaitTime))
+		return s, withExitCode(exitStoreUnreachable, err)
+	case store.File:
+		s, err := store.NewFileStore(coreConfig.StorePath)
+		return s, withExitCode(exitStoreUnreachable, err)
+	default:
+		return nil, withExitCode(exitConfigLoad, fmt.Errorf("unknown StoreType %q", coreConfig.StoreType))
 	}
-	if fails >= coreConfig.FailLimit {
-		return nil, errors.New("Cannot get connection to Consul")
-	}
-
-	// Connect to the Consul Agent
-	configTemp := consulDefaultConfig()
-	configTemp.Address = consulUrl
-
-	return consulNewClient(configTemp)
 }
 
-// Remove all values in Consul K/V store, under the globalprefix which is presents in configuration file.
-func removeStoredConfig(kv *consulapi.KV) {
-	_, err := consulDeleteTree(kv, pkg.CoreConfiguration.GlobalPrefix, nil)
+// Remove all values in the K/V store, under the globalprefix which is presents in configuration file.
+func removeStoredConfig(coreConfig pkg.CoreConfig, s store.ConfigStore) error {
+	// The trailing slash keeps sibling prefixes such as the SnapshotPrefix.
+	err := s.DeleteTree(coreConfig.GlobalPrefix + "/")
 	if err != nil {
-		fmt.Println(err.Error())
-		return
+		return withExitCode(exitPartialWrite, err)
 	}
-	fmt.Println("All values under the globalPrefix(\"" + pkg.CoreConfiguration.GlobalPrefix + "\") is removed.")
+	fmt.Println("All values under the globalPrefix(\"" + coreConfig.GlobalPrefix + "\") is removed.")
+	return nil
 }
 
-// Check if Consul has been configured by trying to get any key that starts with a globalprefix.
-func isConfigInitialized(coreConfig pkg.CoreConfig, kv *consulapi.KV) bool {
-	keys, _, err := consulKeys(kv, coreConfig.GlobalPrefix, "", nil)
+// Check if the K/V store has been configured by trying to get any key that starts with a globalprefix.
+func isConfigInitialized(coreConfig pkg.CoreConfig, s store.ConfigStore) bool {
+	keys, err := s.Keys(coreConfig.GlobalPrefix)
 	if err != nil {
 		fmt.Println(err.Error())
 		return false
@@ -191,7 +237,7 @@ func isConfigInitialized(coreConfig pkg.CoreConfig, kv *consulapi.KV) bool {
 	return false
 }
 
-// Load a property file(.yaml or .properties) and parse it to a map.
+// Load a property file(.toml, .yaml, .json, .hcl or .properties) and parse it to a map.
 func readPropertyFile(coreConfig pkg.CoreConfig, filePath string) (pkg.ConfigProperties, error) {
 
 	if isTomlExtension(coreConfig, filePath) {
@@ -200,6 +246,12 @@ func readPropertyFile(coreConfig pkg.CoreConfig, filePath string) (pkg.ConfigPro
 	} else if isYamlExtension(coreConfig, filePath) {
 		// Read .yaml/.yml file
 		return readYamlFile(filePath)
+	} else if isJsonExtension(coreConfig, filePath) {
+		// Read .json file
+		return readJsonFile(filePath)
+	} else if isHclExtension(coreConfig, filePath) {
+		// Read .hcl file
+		return readHclFile(filePath)
 	} else {
 		// Read .properties file
 		return readPropertiesFile(filePath)
@@ -208,128 +260,231 @@ func readPropertyFile(coreConfig pkg.CoreConfig, filePath string) (pkg.ConfigPro
 }
 
 
+// Flattened configuration of one service directory, ready to be put to Consul K/V store.
+type serviceConfig struct {
+	// Dir is the service directory relative to its config path, with a trailing slash.
+	Dir string
+	// Prefix is the Consul key every property of the service is stored under.
+	Prefix string
+	// Props maps keys relative to Prefix to their values.
+	Props pkg.ConfigProperties
+	// Files are the paths of the files read, the lowest layer first.
+	Files []string
+	// Layers maps every key to the name of the file which set it last, or of the environment
+	// variable overriding it.
+	Layers map[string]string
+	// Secrets holds the values replaced by references to the secret store, by key.
+	Secrets pkg.ConfigProperties
+}
+
 // V2 Config changes in parsing and loading.
-// NOTE a simple inline test so you can read the values out after you push them.
-// TODO: change the inline to an external test and run it against the file
-func loadV2ConfigFromPath(profile string, coreConfig pkg.CoreConfig, kv *consulapi.KV) {
+// Walk the V2 config path and flatten the configuration of every service, the files of the profiles
+// being deep-merged over the base file.
+func readV2ConfigFromPath(profile string, coreConfig pkg.CoreConfig) ([]*serviceConfig, error) {
+	services := []*serviceConfig{}
+	invalid := false
+	files := layer.Files(profile)
+	overrides := readEnvOverrides()
+	overridden := map[string]string{}
+	vars, err := readVariables(profile, coreConfig)
+	if err != nil {
+		return nil, err
+	}
 
-	err := filepath.Walk(coreConfig.ConfigPathV2, func(path string, info os.FileInfo, err error) error {
+	err = filepath.Walk(coreConfig.ConfigPathV2, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
-			return err
+			return withExitCode(exitConfigLoad, err)
 		}
 
-		// Skip directories & unacceptable property extension
-		if info.IsDir() || !isAcceptablePropertyExtensions(coreConfig, info.Name()) {
+		// Every service directory holds its layers
+		if !info.IsDir() {
 			return nil
 		}
+		rel, err := filepath.Rel(coreConfig.ConfigPathV2, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		dir := filepath.ToSlash(rel) + "/"
 
-		dir, file := filepath.Split(path)
-		configPath, err := filepath.Rel(".", coreConfig.ConfigPathV2)
+		m, origins, paths, err := readV2Layers(path, files)
 		if err != nil {
 			return err
 		}
-
-		// Use correct configFileName
-		fileName := determineConfigFile(profile)
-
-		// Skip incorrect configs
-		if !determineCorrectConfigFile(fileName, file) {
+		if len(paths) == 0 {
 			return nil
 		}
-
-		dir = strings.TrimPrefix(dir, configPath+"/")
-		fmt.Println("found config file:", file, "in context", dir)
-
-		// load the ToML file
-		config,_ := toml.LoadFile(path)
-
-		// Fetch the map[string]interface{}
-		m := config.ToMap()
+		encrypted := map[string]bool{}
+		for _, p := range paths {
+			fmt.Fprintln(logOutput, "found config file:", filepath.Base(p), "in context", dir)
+			encrypted[filepath.Base(p)] = isEncryptedFile(p)
+		}
 
 		// traverse the map and put into KV[]
-		kvs, err := traverse("",m)
+		kvs, err := traverse("", m)
 		if err != nil {
-			fmt.Printf("There was an error: %v", err)
-		}
-		for _, kv := range kvs {
-			fmt.Println("v2 consul wrote key", kv.Key, "with value", kv.Value)
+			return withExitCode(exitParse, err)
 		}
 
-		// Put config properties to Consul K/V store.
-		prefix := coreConfig.GlobalPrefix + "/" + dir
-
-		// Put config properties to Consul K/V store.
-		for _,v := range kvs {
-			p := &consulapi.KVPair{Key: prefix + v.Key, Value: []byte(v.Value)}
-			if _, err := consulPut(kv, p, nil); err != nil {
-				return err
+		service := &serviceConfig{Dir: dir, Prefix: coreConfig.GlobalPrefix + "/" + dir,
+			Props: pkg.ConfigProperties{}, Files: paths, Layers: map[string]string{}}
+		for _, kv := range kvs {
+			service.Props[kv.Key] = kv.Value
+			service.Layers[kv.Key] = origins[kv.Key]
+			if encrypted[origins[kv.Key]] {
+				secrets.Add(service.Prefix + kv.Key)
+				ciphertexts[service.Prefix+kv.Key] = ""
 			}
 		}
+		if err := applyEnvOverrides(service, overrides, overridden); err != nil {
+			return withExitCode(exitConfigLoad, err)
+		}
+		if err := decryptValues(service); err != nil {
+			return err
+		}
+		if err := interpolate(service, vars); err != nil {
+			return err
+		}
 
-		// TEST make sure we have our values from Consul
-		// Let's read the values from Consul K/V store now
-		// In our clients we hook this up and we can receive updates from consul changes
-		updateCh := make(chan interface{})
-		errCh := make(chan error)
-		d := &consulstructure.Decoder{
-			Target:   &types.EdgeX_Core_Command{},
-			Prefix:   "config/EdgeX_Core_Command",
-			UpdateCh: updateCh,
+		// Check the final values against the struct of the service before anything is written
+		resolveValues(m, kvs, service.Props)
+		if !validateV2Config(strings.TrimSuffix(dir, "/"), strings.Join(paths, " + "), m) {
+			invalid = true
+			return nil
+		}
+		moveSecrets(service, coreConfig)
+		if len(files) > 1 {
+			printLayers(logOutput, service)
 		}
-		defer d.Close()
-		go d.Run()
+		services = append(services, service)
+		return nil
+	})
 
+	if err == nil && invalid {
+		err = withExitCode(exitValidation, errors.New("V2 configuration does not match the types of its services"))
+	}
+	return services, err
+}
 
-		// NOTE: Place breakpoint here..change the actual loaded values in consul
-		//       They should be pulled via the channel update call and you should see the changes here.
-		//       Look at "actual" value for the map[string]interface{}
-		var raw interface{}
-		select {
-		case <-time.After(1 * time.Second):
-			fmt.Printf("timeout")
-		case err := <-errCh:
-			fmt.Printf("err: %s", err)
-		case raw = <-updateCh:
+// Validate a V2 configuration file against the registered type of its service, printing every problem found.
+// Services without a registered type are not validated.
+func validateV2Config(service string, path string, config map[string]interface{}) bool {
+	st, ok := types.Lookup(service)
+	if !ok {
+		fmt.Fprintln(logOutput, "no type registered for service", service, "- skipping validation of", path)
+		return true
+	}
+
+	errs := st.Validate(config)
+	for _, e := range errs {
+		fmt.Fprintf(logOutput, "%s: %s\n", path, e.Error())
+	}
+	return len(errs) == 0
+}
+
+// Put the values changed since parsing, by environment overrides, decryption or interpolation, back
+// into the merged configuration of a service as types.Resolved, so that the values validated are
+// those written. Keys added by environment overrides are added to it.
+func resolveValues(config map[string]interface{}, parsed []*KV, props pkg.ConfigProperties) {
+	original := map[string]string{}
+	for _, kv := range parsed {
+		original[kv.Key] = kv.Value
+	}
+	for k, v := range props {
+		if o, ok := original[k]; ok && o == v {
+			continue
 		}
+		setValue(config, strings.Split(k, "/"), types.Resolved(v))
+	}
+}
 
-		actual := raw.(*types.EdgeX_Core_Command)
-		if actual== nil {
-			fmt.Printf("bad: %#v", actual)
+// Set the value at a key path of a parsed configuration, creating the missing tables. Paths into
+// lists must name an existing element.
+func setValue(node interface{}, path []string, v interface{}) {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if len(path) == 1 {
+			n[path[0]] = v
+			return
+		}
+		child, ok := n[path[0]]
+		if !ok {
+			child = map[string]interface{}{}
+			n[path[0]] = child
+		}
+		setValue(child, path[1:], v)
+	case []interface{}:
+		i, err := strconv.Atoi(path[0])
+		if err != nil || i < 0 || i >= len(n) {
+			return
 		}
+		if len(path) == 1 {
+			n[i] = v
+			return
+		}
+		setValue(n[i], path[1:], v)
+	}
+}
 
-		// END TEST client read from consul
+// V2 Config - Put the configuration of every service read by readV2ConfigFromPath to Consul K/V store.
+func putV2Services(services []*serviceConfig, coreConfig pkg.CoreConfig, s store.ConfigStore) error {
+	if err := writeSecrets(services, coreConfig); err != nil {
+		return withExitCode(exitPartialWrite, err)
+	}
 
-		return nil
-	})
+	for _, service := range services {
+		if err := putV2ServiceConfig(service, s); err != nil {
+			return withExitCode(exitPartialWrite, err)
+		}
+	}
+	return nil
+}
 
-	// Special case V2 config parsing here
+func putV2ServiceConfig(service *serviceConfig, s store.ConfigStore) error {
+	for k, v := range service.Props {
+		fmt.Println("v2 consul wrote key", k, "with value", secrets.Redact(service.Prefix+k, v))
+	}
 
-	if err != nil {
-		fmt.Println(err.Error())
-		return
+	// Put config properties to the K/V store.
+	for k, v := range service.Props {
+		if err := s.Put(service.Prefix+k, []byte(v)); err != nil {
+			return err
+		}
 	}
+
+	return nil
 }
 
-// V1 Config - Load all config files and put the configuration info to Consul K/V store.
-func loadConfigFromPath(coreConfig pkg.CoreConfig, kv *consulapi.KV) {
+// V1 Config - Walk the config path and parse every acceptable file, grouped by service directory.
+// The profile only selects the variables, V1 profiles being directories of their own.
+func readConfigFromPath(profile string, coreConfig pkg.CoreConfig) ([]*serviceConfig, error) {
+	services := []*serviceConfig{}
+	byDir := map[string]*serviceConfig{}
+
 	err := filepath.Walk(coreConfig.ConfigPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
-			return err
+			return withExitCode(exitConfigLoad, err)
 		}
 
 		// Skip directories & unacceptable property extension
 		if info.IsDir() || !isAcceptablePropertyExtensions(coreConfig, info.Name()) {
 			return nil
 		}
+		// A directory read as both config paths leaves the V2 layers to readV2ConfigFromPath.
+		if coreConfig.ConfigPath == coreConfig.ConfigPathV2 && isTomlExtension(coreConfig, info.Name()) &&
+			strings.HasPrefix(info.Name(), "configuration") {
+			return nil
+		}
 
 		dir, file := filepath.Split(path)
-		configPath, err := filepath.Rel(".", coreConfig.ConfigPath)
+		rel, err := filepath.Rel(coreConfig.ConfigPath, dir)
 		if err != nil {
 			return err
 		}
-
-		dir = strings.TrimPrefix(dir, configPath+"/")
-		fmt.Println("found config file:", file, "in context", dir)
+		dir = ""
+		if rel != "." {
+			dir = filepath.ToSlash(rel) + "/"
+		}
+		fmt.Fprintln(logOutput, "found config file:", file, "in context", dir)
 
 		// Parse *.properties
 		props, err := readPropertyFile(coreConfig, path)
@@ -337,24 +492,66 @@ func loadConfigFromPath(coreConfig pkg.CoreConfig, kv *consulapi.KV) {
 			return err
 		}
 
-		// Put config properties to Consul K/V store.
-		prefix := coreConfig.GlobalPrefix + "/" + dir
-		// here we need to make sure we add all the keys as appropriate
-		for k := range props {
-			p := &consulapi.KVPair{Key: prefix + k, Value: []byte(props[k])}
-			if _, err := consulPut(kv, p, nil); err != nil {
-				return err
+		// Several files in one directory share the prefix of the service.
+		service, ok := byDir[dir]
+		if !ok {
+			service = &serviceConfig{Dir: dir, Prefix: coreConfig.GlobalPrefix + "/" + dir,
+				Props: pkg.ConfigProperties{}, Layers: map[string]string{}}
+			byDir[dir] = service
+			services = append(services, service)
+		}
+		service.Files = append(service.Files, path)
+		encrypted := isEncryptedFile(path)
+		for k, v := range props {
+			service.Props[k] = v
+			service.Layers[k] = file
+			if encrypted {
+				secrets.Add(service.Prefix + k)
+				ciphertexts[service.Prefix+k] = ""
 			}
 		}
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
 
-	// Special case V2 config parsing here
-
+	vars, err := readVariables(profile, coreConfig)
 	if err != nil {
-		fmt.Println(err.Error())
-		return
+		return nil, err
 	}
+	overrides := readEnvOverrides()
+	overridden := map[string]string{}
+	for _, service := range services {
+		if err := applyEnvOverrides(service, overrides, overridden); err != nil {
+			return nil, withExitCode(exitConfigLoad, err)
+		}
+		if err := decryptValues(service); err != nil {
+			return nil, err
+		}
+		if err := interpolate(service, vars); err != nil {
+			return nil, err
+		}
+		moveSecrets(service, coreConfig)
+	}
+	return services, nil
+}
+
+// V1 Config - Put the configuration info read by readConfigFromPath to Consul K/V store.
+func putServices(services []*serviceConfig, coreConfig pkg.CoreConfig, s store.ConfigStore) error {
+	if err := writeSecrets(services, coreConfig); err != nil {
+		return withExitCode(exitPartialWrite, err)
+	}
+
+	for _, service := range services {
+		// here we need to make sure we add all the keys as appropriate
+		for k := range service.Props {
+			if err := s.Put(service.Prefix+k, []byte(service.Props[k])); err != nil {
+				return withExitCode(exitPartialWrite, err)
+			}
+		}
+	}
+	return nil
 }
 
 func isAcceptablePropertyExtensions(coreConfig pkg.CoreConfig, file string) bool {
@@ -385,73 +582,208 @@ func isTomlExtension(coreConfig pkg.CoreConfig, file string) bool {
 	return false
 }
 
-//This works for now because our TOML is simply key/value.
-//Will not work once we go hierarchical
+func isJsonExtension(coreConfig pkg.CoreConfig, file string) bool {
+	for _, v := range coreConfig.JsonExtensions {
+		if v == filepath.Ext(file) {
+			return true
+		}
+	}
+	return false
+}
+
+func isHclExtension(coreConfig pkg.CoreConfig, file string) bool {
+	for _, v := range coreConfig.HclExtensions {
+		if v == filepath.Ext(file) {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse a toml file to a map. Tables, arrays of tables and inline tables are flattened the same way
+// as V2 configuration, e.g. "Port" in "[Service]" becomes "Service/Port". Flat files keep their keys.
 func readTomlFile(filePath string) (pkg.ConfigProperties, error) {
 	configProps := pkg.ConfigProperties{}
 
-	file, err := os.Open(filePath)
+	contents, err := readConfigFile(filePath)
 	if err != nil {
-		return configProps, fmt.Errorf("could not load configuration file (%s): %v", filePath, err.Error())
+		return configProps, err
+	}
+	tree, err := toml.LoadBytes(contents)
+	if err != nil {
+		return configProps, withExitCode(exitParse, fmt.Errorf("could not load configuration file (%s): %v", filePath, err.Error()))
 	}
-	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	scanner.Split(bufio.ScanLines)
+	kvs, err := traverse("", tree.ToMap())
+	if err != nil {
+		return configProps, err
+	}
+	for _, kv := range kvs {
+		configProps[kv.Key] = kv.Value
+	}
+	return configProps, nil
+}
 
-	for scanner.Scan() {
-		line := scanner.Text()   // The Line of text were gathering
+// Parse a yaml file to a map. Nested mappings and lists are flattened like V2 configuration,
+// e.g. "server: {port: 8080}" becomes "server/port". Anchors and merge keys are resolved by the parser.
+// In a file of several documents the keys of a later document override those of an earlier one.
+func readYamlFile(filePath string) (pkg.ConfigProperties, error) {
 
+	configProps := pkg.ConfigProperties{}
 
-		if strings.Contains(line, "[") {
-			// Parse the text until the next "]"
+	contents, err := readConfigFile(filePath)
 
+	if err != nil {
+		return nil, err
+	}
 
+	decoder := yaml.NewDecoder(bytes.NewReader(contents))
+	for n := 1; ; n++ {
+		var doc interface{}
+		if err = decoder.Decode(&doc); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, withExitCode(exitParse, fmt.Errorf("could not parse yaml file (%s): %v", filePath, err))
+		}
 
+		// Empty documents, such as the one after a trailing "---", hold no keys.
+		if doc == nil {
+			continue
+		}
+		m, ok := normalizeYaml(doc).(map[string]interface{})
+		if !ok {
+			return nil, withExitCode(exitParse, fmt.Errorf("could not parse yaml file (%s): document %d is not a mapping", filePath, n))
 		}
-		if strings.Contains(line, "=") {
-			tokens := strings.Split(scanner.Text(), "=")
-			configProps[strings.Trim(tokens[0], " '")] = strings.Trim(tokens[1], " '")
+		kvs, err := traverse("", m)
+		if err != nil {
+			return nil, err
 		}
+		for _, kv := range kvs {
+			configProps[kv.Key] = kv.Value
+		}
+	}
 
+	return configProps, nil
+}
 
+// Convert the map[interface{}]interface{} mappings produced by the yaml parser to the
+// map[string]interface{} understood by traverse.
+func normalizeYaml(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, sv := range v {
+			m[fmt.Sprint(k)] = normalizeYaml(sv)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, sv := range v {
+			l[i] = normalizeYaml(sv)
+		}
+		return l
+	default:
+		return v
 	}
-	return configProps, nil
 }
 
-// Parse a yaml file to a map.
-func readYamlFile(filePath string) (pkg.ConfigProperties, error) {
+// Parse a json file to a map. Objects and arrays are flattened like V2 configuration,
+// numbers keep the text they have in the file.
+func readJsonFile(filePath string) (pkg.ConfigProperties, error) {
 
 	configProps := pkg.ConfigProperties{}
 
-	contents, err := ioutil.ReadFile(filePath)
+	contents, err := readConfigFile(filePath)
+	if err != nil {
+		return nil, err
+	}
 
+	var doc interface{}
+	decoder := json.NewDecoder(bytes.NewReader(contents))
+	decoder.UseNumber()
+	if err = decoder.Decode(&doc); err != nil {
+		return nil, withExitCode(exitParse, fmt.Errorf("could not parse json file (%s): %v", filePath, err))
+	}
+
+	kvs, err := traverse("", doc)
 	if err != nil {
 		return nil, err
 	}
+	for _, kv := range kvs {
+		configProps[kv.Key] = kv.Value
+	}
 
-	if err = yaml.Unmarshal(contents, configProps); err != nil {
+	return configProps, nil
+}
+
+// Parse a hcl file to a map. Blocks and lists are flattened like V2 configuration,
+// e.g. "Service { Port = 48080 }" becomes "Service/Port".
+func readHclFile(filePath string) (pkg.ConfigProperties, error) {
+
+	configProps := pkg.ConfigProperties{}
+
+	contents, err := readConfigFile(filePath)
+	if err != nil {
 		return nil, err
 	}
 
-	m := structs.Map(configProps)
+	doc := map[string]interface{}{}
+	if err = hcl.Unmarshal(contents, &doc); err != nil {
+		return nil, withExitCode(exitParse, fmt.Errorf("could not parse hcl file (%s): %v", filePath, err))
+	}
 
-	for key, value := range m {
-		m[key] = fmt.Sprint(value)
+	kvs, err := traverse("", normalizeHcl(doc))
+	if err != nil {
+		return nil, err
+	}
+	for _, kv := range kvs {
+		configProps[kv.Key] = kv.Value
 	}
 
 	return configProps, nil
 }
 
+// The hcl parser decodes every block to a list of objects. Collapse a block given once to
+// its object so that it is flattened without an index, and convert the lists for traverse.
+func normalizeHcl(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, sv := range v {
+			v[k] = normalizeHcl(sv)
+		}
+		return v
+	case []map[string]interface{}:
+		if len(v) == 1 {
+			return normalizeHcl(v[0])
+		}
+		l := make([]interface{}, len(v))
+		for i, sv := range v {
+			l[i] = normalizeHcl(sv)
+		}
+		return l
+	case []interface{}:
+		for i, sv := range v {
+			v[i] = normalizeHcl(sv)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
 // Parse a properties file to a map.
 func readPropertiesFile(filePath string) (pkg.ConfigProperties, error) {
 
 	configProps := pkg.ConfigProperties{}
 
-	props, err := properties.LoadFile(filePath, properties.UTF8)
+	contents, err := readConfigFile(filePath)
 	if err != nil {
 		return nil, err
 	}
+	props, err := properties.Load(contents, properties.UTF8)
+	if err != nil {
+		return nil, withExitCode(exitParse, fmt.Errorf("could not parse properties file (%s): %v", filePath, err))
+	}
 	configProps = props.Map()
 
 	return configProps, nil
@@ -493,19 +825,20 @@ func traverse(path string, j interface{}) ([]*KV, error) {
 	case int:
 		kvs = append(kvs, &KV{Key: path, Value: strconv.Itoa(j.(int))})
 	case int64:
-
-		var y int = int(j.(int64))
-
-		kvs = append(kvs, &KV{Key: path, Value: strconv.Itoa(y)})
-		//kvs = append(kvs, &KV{Key: path, Value: strconv.FormatInt(j.(int64),64)})
+		kvs = append(kvs, &KV{Key: path, Value: strconv.FormatInt(j.(int64), 10)})
 	case float64:
 		kvs = append(kvs, &KV{Key: path, Value: strconv.FormatFloat(j.(float64), 'f', -1, 64)})
 	case bool:
 		kvs = append(kvs, &KV{Key: path, Value: strconv.FormatBool(j.(bool))})
 	case nil:
 		kvs = append(kvs, &KV{Key: path, Value: ""})
-	default:
+	case json.Number:
+		kvs = append(kvs, &KV{Key: path, Value: j.(json.Number).String()})
+	case string:
 		kvs = append(kvs, &KV{Key: path, Value: j.(string)})
+	default:
+		// Dates and times of TOML among others.
+		kvs = append(kvs, &KV{Key: path, Value: fmt.Sprint(j)})
 	}
 
 	return kvs, nil
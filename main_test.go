@@ -0,0 +1,225 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Write contents to a file named name in a temporary directory, returning its path and a cleanup.
+func writeTestFile(t *testing.T, name string, contents string) (string, func()) {
+	dir, err := ioutil.TempDir("", "config-seed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func TestReadTomlFileFlat(t *testing.T) {
+	props, err := readTomlFile("config/edgex-core-data;go/configuration.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"ServicePort":      "48080",
+		"MongoDBKeepAlive": "true",
+		"AppOpenMsg":       "This is the Core Data Micro Service",
+		"FormatSpecifier":  `%(\\d=\\$)?([-#= 0(\\<]*)?(\\d=)?(\\.\\d=)?([tT])?([a-zA-Z%])`,
+	}
+	for k, v := range expected {
+		if props[k] != v {
+			t.Errorf("%s: expected %q, got %q", k, v, props[k])
+		}
+	}
+}
+
+func TestReadTomlFileHierarchical(t *testing.T) {
+	path, cleanup := writeTestFile(t, "configuration.toml", `
+Name = 'flat'
+[Service]
+Host = 'localhost'
+Port = 48080
+Labels = ['a', 'b']
+Origin = { Host = 'edgex', Port = 1 }
+
+[[Devices]]
+Name = 'first'
+
+[[Devices]]
+Name = 'second'
+`)
+	defer cleanup()
+
+	props, err := readTomlFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"Name":                "flat",
+		"Service/Host":        "localhost",
+		"Service/Port":        "48080",
+		"Service/Labels/0":    "a",
+		"Service/Labels/1":    "b",
+		"Service/Origin/Host": "edgex",
+		"Service/Origin/Port": "1",
+		"Devices/0/Name":      "first",
+		"Devices/1/Name":      "second",
+	}
+	if len(props) != len(expected) {
+		t.Errorf("expected %d keys, got %#v", len(expected), props)
+	}
+	for k, v := range expected {
+		if props[k] != v {
+			t.Errorf("%s: expected %q, got %q", k, v, props[k])
+		}
+	}
+}
+
+func TestReadYamlFile(t *testing.T) {
+	path, cleanup := writeTestFile(t, "application.yml", `
+defaults: &defaults
+  host: localhost
+  port: 48080
+server.port: 49990
+service:
+  <<: *defaults
+  labels:
+    - modbus
+    - { name: virtual }
+---
+service:
+  port: 48081
+`)
+	defer cleanup()
+
+	props, err := readYamlFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"defaults/host":         "localhost",
+		"defaults/port":         "48080",
+		"server.port":           "49990",
+		"service/host":          "localhost",
+		"service/port":          "48081",
+		"service/labels/0":      "modbus",
+		"service/labels/1/name": "virtual",
+	}
+	if len(props) != len(expected) {
+		t.Errorf("expected %d keys, got %#v", len(expected), props)
+	}
+	for k, v := range expected {
+		if props[k] != v {
+			t.Errorf("%s: expected %q, got %q", k, v, props[k])
+		}
+	}
+}
+
+// Empty documents are skipped and documents which are not mappings are parse errors.
+func TestReadYamlDocuments(t *testing.T) {
+	path, cleanup := writeTestFile(t, "application.yml", "---\nserver.port: 49990\n---\n---\nservice:\n  port: 48081\n---\n")
+	defer cleanup()
+
+	props, err := readYamlFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(props) != 2 || props["server.port"] != "49990" || props["service/port"] != "48081" {
+		t.Errorf("unexpected keys %#v", props)
+	}
+
+	for _, contents := range []string{"server.port: 49990\n---\n- a\n- b\n", "just a value\n"} {
+		path, cleanup := writeTestFile(t, "application.yml", contents)
+		defer cleanup()
+		if _, err := readYamlFile(path); exitCode(err) != exitParse {
+			t.Errorf("%q: expected a parse error, got %v", contents, err)
+		}
+	}
+}
+
+func TestReadJsonFile(t *testing.T) {
+	path, cleanup := writeTestFile(t, "configuration.json", `{
+  "Service": {"Host": "localhost", "Port": 48080, "Timeout": 1.5},
+  "Labels": ["a", "b"],
+  "EnableRemote": false
+}`)
+	defer cleanup()
+
+	props, err := readJsonFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"Service/Host":    "localhost",
+		"Service/Port":    "48080",
+		"Service/Timeout": "1.5",
+		"Labels/0":        "a",
+		"Labels/1":        "b",
+		"EnableRemote":    "false",
+	}
+	if len(props) != len(expected) {
+		t.Errorf("expected %d keys, got %#v", len(expected), props)
+	}
+	for k, v := range expected {
+		if props[k] != v {
+			t.Errorf("%s: expected %q, got %q", k, v, props[k])
+		}
+	}
+}
+
+func TestReadHclFile(t *testing.T) {
+	path, cleanup := writeTestFile(t, "configuration.hcl", `
+Name = "flat"
+Service {
+  Host = "localhost"
+  Port = 48080
+}
+Device { Name = "first" }
+Device { Name = "second" }
+`)
+	defer cleanup()
+
+	props, err := readHclFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"Name":          "flat",
+		"Service/Host":  "localhost",
+		"Service/Port":  "48080",
+		"Device/0/Name": "first",
+		"Device/1/Name": "second",
+	}
+	if len(props) != len(expected) {
+		t.Errorf("expected %d keys, got %#v", len(expected), props)
+	}
+	for k, v := range expected {
+		if props[k] != v {
+			t.Errorf("%s: expected %q, got %q", k, v, props[k])
+		}
+	}
+}
@@ -0,0 +1,268 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+// Package client loads the V2 configuration seeded for a service and keeps it up to date.
+//
+// A service loads its configuration into the struct registered for it in pkg/v2/types:
+//
+//	config := &types.EdgeX_Core_Data{}
+//	updates, err := client.Load(ctx, "EdgeX_Core_Data", config)
+//
+// Every change of its keys in Consul is then sent on updates as a new *types.EdgeX_Core_Data,
+// until ctx is done.
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg/layer"
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg/secret"
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/pelletier/go-toml"
+)
+
+// Client reads the configuration of the services from Consul, or from their TOML files
+// when the Consul agent cannot be reached. The references to Vault the seed puts in place of
+// the secrets are replaced with their values.
+type Client struct {
+	// Consul is the configuration of the Consul agent, consulapi.DefaultConfig() when nil.
+	Consul *consulapi.Config
+	// Prefix is the global prefix the configuration is seeded under.
+	Prefix string
+	// ConfigPath holds one directory of TOML files per service, laid out like the ConfigPathV2 of the seed.
+	ConfigPath string
+	// Profile is a comma-separated list of profiles whose configuration-<profile>.toml files are
+	// merged over configuration.toml in order, as the seed does.
+	Profile string
+	// VaultAddress is the address of the Vault server holding the secrets, e.g. http://localhost:8200.
+	VaultAddress string
+	// VaultToken is the token of the Vault server, the VAULT_TOKEN environment variable when empty.
+	VaultToken string
+	// RetryInterval is how long the watch waits before querying Consul again after a failure.
+	RetryInterval time.Duration
+}
+
+// DefaultClient is the Client used by Load.
+var DefaultClient = &Client{
+	Prefix:        "config",
+	ConfigPath:    "./res",
+	VaultAddress:  "http://localhost:8200",
+	RetryInterval: 5 * time.Second,
+}
+
+// Load decodes the configuration of a service into target using DefaultClient.
+func Load(ctx context.Context, service string, target interface{}) (<-chan interface{}, error) {
+	return DefaultClient.Load(ctx, service, target)
+}
+
+// Load decodes the keys of a service under the global prefix into target, a pointer to the struct of
+// the service registered in pkg/v2/types. When the Consul agent cannot be reached, or the
+// service has not been seeded yet, the TOML files of the service are decoded instead. Any other
+// error of Consul, such as a denied ACL token, is returned.
+//
+// The returned channel receives a new value of the type of target every time the keys of the service
+// change in Consul, including when it becomes reachable after a fallback. It is closed when ctx is done.
+func (c *Client) Load(ctx context.Context, service string, target interface{}) (<-chan interface{}, error) {
+	if v := reflect.ValueOf(target); v.Kind() != reflect.Ptr || v.IsNil() {
+		return nil, fmt.Errorf("target of service %s must be a non-nil pointer, got %T", service, target)
+	}
+
+	consulConfig := c.Consul
+	if consulConfig == nil {
+		consulConfig = consulapi.DefaultConfig()
+	}
+	consul, err := consulapi.NewClient(consulConfig)
+	if err != nil {
+		return nil, err
+	}
+	kv := consul.KV()
+	prefix := c.Prefix + "/" + service + "/"
+
+	var index uint64
+	pairs, meta, err := kv.List(prefix, (&consulapi.QueryOptions{}).WithContext(ctx))
+	switch {
+	case err == nil && len(pairs) != 0:
+		index = meta.LastIndex
+		err = c.decode(pairsToProps(prefix, pairs), target)
+	case err == nil || isUnreachable(err):
+		err = c.loadFile(service, target)
+	default:
+		err = fmt.Errorf("could not read the configuration of %s from Consul: %v", service, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make(chan interface{})
+	go c.watch(ctx, kv, prefix, index, reflect.ValueOf(target).Elem().Interface(), updates)
+	return updates, nil
+}
+
+// Whether an error of the Consul client comes from the agent not answering, rather than from an answer.
+func isUnreachable(err error) bool {
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// Nest the flattened keys of a service, resolve their references to Vault and decode them into target.
+func (c *Client) decode(props map[string]string, target interface{}) error {
+	root, err := Unflatten(props)
+	if err != nil {
+		return err
+	}
+	if _, err := c.resolveSecrets(root, map[string]map[string]string{}); err != nil {
+		return err
+	}
+	return decodeMap(root, target)
+}
+
+// Decode the layered TOML files of a service into target, the files of the profiles being merged
+// over the base file. Missing files are skipped.
+func (c *Client) loadFile(service string, target interface{}) error {
+	merged := map[string]interface{}{}
+	found := false
+	for _, file := range layer.Files(c.Profile) {
+		path := filepath.Join(c.ConfigPath, service, file)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+		tree, err := toml.LoadFile(path)
+		if err != nil {
+			return fmt.Errorf("could not load configuration file (%s): %v", path, err)
+		}
+		layer.Merge(merged, tree.ToMap())
+		found = true
+	}
+	if !found {
+		return fmt.Errorf("no configuration file of %s in %s", service, filepath.Join(c.ConfigPath, service))
+	}
+
+	if _, err := c.resolveSecrets(merged, map[string]map[string]string{}); err != nil {
+		return err
+	}
+	return decodeMap(merged, target)
+}
+
+// Replace the references to Vault in a configuration with the secrets they point to, read holding
+// the secrets already read by path.
+func (c *Client) resolveSecrets(v interface{}, read map[string]map[string]string) (interface{}, error) {
+	switch n := v.(type) {
+	case map[string]interface{}:
+		for k, sv := range n {
+			r, err := c.resolveSecrets(sv, read)
+			if err != nil {
+				return nil, err
+			}
+			n[k] = r
+		}
+	case []interface{}:
+		for i, sv := range n {
+			r, err := c.resolveSecrets(sv, read)
+			if err != nil {
+				return nil, err
+			}
+			n[i] = r
+		}
+	case string:
+		mount, path, key, ok := secret.ParseReference(n)
+		if !ok {
+			return v, nil
+		}
+		secrets, ok := read[mount+"/"+path]
+		if !ok {
+			token := c.VaultToken
+			if token == "" {
+				token = os.Getenv("VAULT_TOKEN")
+			}
+			var err error
+			secrets, err = secret.NewVaultStore(c.VaultAddress, token, mount, nil).Get(path)
+			if err != nil {
+				return nil, fmt.Errorf("could not resolve %s: %v", n, err)
+			}
+			read[mount+"/"+path] = secrets
+		}
+		value, ok := secrets[key]
+		if !ok {
+			return nil, fmt.Errorf("could not resolve %s: no such secret", n)
+		}
+		return value, nil
+	}
+	return v, nil
+}
+
+// Follow the keys under prefix with blocking queries and send every new configuration on updates.
+func (c *Client) watch(ctx context.Context, kv *consulapi.KV, prefix string, index uint64, last interface{}, updates chan<- interface{}) {
+	defer close(updates)
+
+	for {
+		pairs, meta, err := kv.List(prefix, (&consulapi.QueryOptions{WaitIndex: index}).WithContext(ctx))
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(c.RetryInterval):
+			}
+			continue
+		}
+
+		// The index goes backwards when the Consul cluster is restored, start again from scratch.
+		if meta.LastIndex < index {
+			index = 0
+			continue
+		}
+		if meta.LastIndex == index || len(pairs) == 0 {
+			index = meta.LastIndex
+			continue
+		}
+		index = meta.LastIndex
+
+		next := reflect.New(reflect.TypeOf(last))
+		if err := c.decode(pairsToProps(prefix, pairs), next.Interface()); err != nil {
+			continue
+		}
+		if reflect.DeepEqual(last, next.Elem().Interface()) {
+			continue
+		}
+		last = next.Elem().Interface()
+
+		select {
+		case <-ctx.Done():
+			return
+		case updates <- next.Interface():
+		}
+	}
+}
+
+func pairsToProps(prefix string, pairs consulapi.KVPairs) map[string]string {
+	props := map[string]string{}
+	for _, pair := range pairs {
+		// Skip the folder keys created by the Consul UI.
+		if strings.HasSuffix(pair.Key, "/") {
+			continue
+		}
+		props[strings.TrimPrefix(pair.Key, prefix)] = string(pair.Value)
+	}
+	return props
+}
@@ -0,0 +1,235 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/core-config-seed-go/pkg/v2/types"
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+// Answer the K/V list queries of a Consul agent with the versions of a tree, one per index.
+// A blocking query on the last index waits until the next version is released or the request ends.
+func newConsulStub(t *testing.T, versions []map[string]string, release <-chan struct{}) *httptest.Server {
+	current := 1
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if index, _ := strconv.Atoi(r.URL.Query().Get("index")); index >= current {
+			select {
+			case <-release:
+				if current < len(versions) {
+					current++
+				}
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		pairs := []consulapi.KVPair{}
+		for k, v := range versions[current-1] {
+			pairs = append(pairs, consulapi.KVPair{Key: k, Value: []byte(v)})
+		}
+		w.Header().Set("X-Consul-Index", strconv.Itoa(current))
+		if err := json.NewEncoder(w).Encode(pairs); err != nil {
+			t.Error(err)
+		}
+	}))
+}
+
+func TestLoadFromConsul(t *testing.T) {
+	release := make(chan struct{})
+	stub := newConsulStub(t, []map[string]string{
+		{
+			"config/EdgeX_Core_Data/Service/Port":          "48080",
+			"config/EdgeX_Core_Data/Clients/Metadata/Host": "edgex-core-metadata",
+			"config/EdgeX_Core_Data/Database/Type":         "mongo",
+		},
+		{
+			"config/EdgeX_Core_Data/Service/Port":          "48090",
+			"config/EdgeX_Core_Data/Clients/Metadata/Host": "edgex-core-metadata",
+			"config/EdgeX_Core_Data/Database/Type":         "mongo",
+		},
+	}, release)
+	defer stub.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &Client{Consul: &consulapi.Config{Address: stub.URL}, Prefix: "config", RetryInterval: 10 * time.Millisecond}
+	config := &types.EdgeX_Core_Data{}
+	updates, err := c.Load(ctx, "EdgeX_Core_Data", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Service.Port != 48080 || config.Clients["Metadata"].Host != "edgex-core-metadata" || config.Database.Type != "mongo" {
+		t.Errorf("unexpected configuration %+v", config)
+	}
+
+	release <- struct{}{}
+	select {
+	case update := <-updates:
+		next, ok := update.(*types.EdgeX_Core_Data)
+		if !ok {
+			t.Fatalf("unexpected update %T", update)
+		}
+		if next.Service.Port != 48090 {
+			t.Errorf("unexpected port %d", next.Service.Port)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no update received")
+	}
+
+	cancel()
+	select {
+	case _, ok := <-updates:
+		if ok {
+			t.Error("unexpected update after cancel")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("updates not closed after cancel")
+	}
+}
+
+func TestLoadFallsBackToFile(t *testing.T) {
+	stub := httptest.NewServer(http.NotFoundHandler())
+	stub.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &Client{Consul: &consulapi.Config{Address: stub.URL}, Prefix: "config", ConfigPath: "../toml", RetryInterval: 10 * time.Millisecond}
+	config := &types.EdgeX_Core_Command{}
+	updates, err := c.Load(ctx, "EdgeX_Core_Command", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Service.Port != 48082 || config.Registry.Type != "consul" || config.MetaData.CommandPath != "/api/v1/command" {
+		t.Errorf("unexpected configuration %+v", config)
+	}
+
+	// The watch keeps retrying Consul until the context is done.
+	cancel()
+	for range updates {
+	}
+}
+
+func TestLoadLayersProfiles(t *testing.T) {
+	stub := httptest.NewServer(http.NotFoundHandler())
+	stub.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &Client{Consul: &consulapi.Config{Address: stub.URL}, Prefix: "config", ConfigPath: "../toml", Profile: "docker", RetryInterval: 10 * time.Millisecond}
+	config := &types.EdgeX_Core_Data{}
+	updates, err := c.Load(ctx, "EdgeX_Core_Data", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Host and Port come from the docker profile, the database name from the base file.
+	if config.Service.Host != "edgex-core-data" || config.Service.Port != 48080 || config.Database.Host != "edgex-mongo" || config.Database.Name != "metadb" {
+		t.Errorf("unexpected configuration %+v", config)
+	}
+	cancel()
+	for range updates {
+	}
+}
+
+func TestLoadReturnsConsulErrors(t *testing.T) {
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Permission denied", http.StatusForbidden)
+	}))
+	defer stub.Close()
+
+	c := &Client{Consul: &consulapi.Config{Address: stub.URL}, Prefix: "config", ConfigPath: "../toml"}
+	_, err := c.Load(context.Background(), "EdgeX_Core_Command", &types.EdgeX_Core_Command{})
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("expected the denied query to be returned, got %v", err)
+	}
+}
+
+func TestLoadResolvesVaultReferences(t *testing.T) {
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/secret/data/edgex/EdgeX_Core_Data" || r.Header.Get("X-Vault-Token") != "root" {
+			http.Error(w, "permission denied", http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"data": {"data": {"Database/Password": "p4ss"}}}`))
+	}))
+	defer vault.Close()
+	stub := newConsulStub(t, []map[string]string{{
+		"config/EdgeX_Core_Data/Database/Username": "admin",
+		"config/EdgeX_Core_Data/Database/Password": "vault:secret/edgex/EdgeX_Core_Data#Database/Password",
+	}}, nil)
+	defer stub.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &Client{Consul: &consulapi.Config{Address: stub.URL}, Prefix: "config", VaultAddress: vault.URL, VaultToken: "root"}
+	config := &types.EdgeX_Core_Data{}
+	updates, err := c.Load(ctx, "EdgeX_Core_Data", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Database.Username != "admin" || config.Database.Password != "p4ss" {
+		t.Errorf("unexpected database %+v", config.Database)
+	}
+	cancel()
+	for range updates {
+	}
+
+	c.VaultToken = "wrong"
+	if _, err := c.Load(context.Background(), "EdgeX_Core_Data", &types.EdgeX_Core_Data{}); err == nil {
+		t.Error("expected an unresolved reference to fail")
+	}
+}
+
+func TestDecode(t *testing.T) {
+	var target struct {
+		Name    string
+		Enabled bool
+		Devices []struct{ Name string }
+	}
+	err := Decode(map[string]string{
+		"Name":           "virtual",
+		"Enabled":        "true",
+		"Devices/0/Name": "first",
+		"Devices/1/Name": "second",
+	}, &target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target.Name != "virtual" || !target.Enabled || len(target.Devices) != 2 || target.Devices[1].Name != "second" {
+		t.Errorf("unexpected target %+v", target)
+	}
+}
+
+func TestUnflattenConflicts(t *testing.T) {
+	for _, props := range []map[string]string{
+		{"Service": "x", "Service/Port": "48080"},
+		{"Service/Port": "48080", "Service/Port/Number": "1"},
+	} {
+		if _, err := Unflatten(props); err == nil || !strings.Contains(err.Error(), "holds a value and") {
+			t.Errorf("%v: expected a conflict, got %v", props, err)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package client
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// Decode flattened keys relative to the prefix of a service, e.g. Service/Port, into target the way
+// consulstructure does. Keys are nested by Unflatten and values are weakly typed, since the K/V store
+// only holds strings.
+func Decode(props map[string]string, target interface{}) error {
+	root, err := Unflatten(props)
+	if err != nil {
+		return err
+	}
+	return decodeMap(root, target)
+}
+
+// Unflatten nests flattened keys on their slashes, Service/Port becoming Port in the table Service,
+// and turns the tables whose keys are 0 to n-1 into lists. Values are left as strings. A key holding
+// a value as well as keys under it, such as Service and Service/Port, is an error.
+func Unflatten(props map[string]string) (map[string]interface{}, error) {
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	// Sorted, a key comes before the keys under it.
+	root := map[string]interface{}{}
+	for _, k := range keys {
+		parts := strings.Split(k, "/")
+		m := root
+		for i, part := range parts[:len(parts)-1] {
+			if _, ok := m[part].(string); ok {
+				return nil, fmt.Errorf("%s holds a value and the key %s", strings.Join(parts[:i+1], "/"), k)
+			}
+			next, ok := m[part].(map[string]interface{})
+			if !ok {
+				next = map[string]interface{}{}
+				m[part] = next
+			}
+			m = next
+		}
+		m[parts[len(parts)-1]] = props[k]
+	}
+	for k, v := range root {
+		root[k] = toLists(v)
+	}
+	return root, nil
+}
+
+func decodeMap(m map[string]interface{}, target interface{}) error {
+	d, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		WeaklyTypedInput: true,
+		Result:           target,
+	})
+	if err != nil {
+		return err
+	}
+	return d.Decode(m)
+}
+
+// Turn the nested tables whose keys are the indexes 0 to n-1 into lists.
+func toLists(v interface{}) interface{} {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+	for k, sv := range m {
+		m[k] = toLists(sv)
+	}
+
+	l := make([]interface{}, len(m))
+	for k, sv := range m {
+		i, err := strconv.Atoi(k)
+		if err != nil || i < 0 || i >= len(m) {
+			return m
+		}
+		l[i] = sv
+	}
+	return l
+}
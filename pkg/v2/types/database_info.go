@@ -31,6 +31,6 @@ type DatabaseInfo struct {
 	Host           string
 	Port           int
 	Username       string
-	Password       string
+	Password       string `secret:"true"`
 	Name           string
 }
\ No newline at end of file
@@ -11,4 +11,12 @@ type EdgeX_Core_Command struct {
 	Logging LoggingInfo
 	// Metadata contains metadata
 	MetaData MetaDataInfo
-}
\ No newline at end of file
+}
+
+func init() {
+	Register(ServiceType{
+		Name:             "EdgeX_Core_Command",
+		New:              func() interface{} { return &EdgeX_Core_Command{} },
+		RequiredSections: []string{"Service", "Registry", "Logging", "MetaData"},
+	})
+}
@@ -14,3 +14,11 @@ type EdgeX_Core_Data struct {
 	// Database
 	Database DatabaseInfo
 }
+
+func init() {
+	Register(ServiceType{
+		Name:             "EdgeX_Core_Data",
+		New:              func() interface{} { return &EdgeX_Core_Data{} },
+		RequiredSections: []string{"Service", "Registry", "Logging", "MetaData", "Database"},
+	})
+}
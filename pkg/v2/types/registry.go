@@ -0,0 +1,105 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package types
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// ServiceType describes the configuration of a V2 service.
+type ServiceType struct {
+	// Name is the directory of the service under the V2 config path, which is also its key
+	// under the global prefix, e.g. EdgeX_Core_Data.
+	Name string
+	// New returns a pointer to an empty configuration struct of the service.
+	New func() interface{}
+	// RequiredSections are the top-level tables every configuration file of the service must have.
+	RequiredSections []string
+	// Secrets are the slash-separated keys of the fields tagged secret:"true", e.g. Database/Password.
+	// Register fills them in from the struct.
+	Secrets []string
+}
+
+// Validate checks a configuration parsed from TOML against the struct of the service.
+func (st ServiceType) Validate(config map[string]interface{}) []ValidationError {
+	return Validate(config, st.New(), st.RequiredSections)
+}
+
+// TypeValues converts the string values of a configuration to the kinds of the fields of the service.
+func (st ServiceType) TypeValues(config map[string]interface{}) {
+	TypeValues(config, st.New())
+}
+
+var (
+	registryMutex sync.RWMutex
+	registry      = map[string]ServiceType{}
+)
+
+// Register makes a service type known to the seed and to the clients, usually from the init function
+// of the file declaring its struct. It panics when a service of the same name is registered twice.
+func Register(st ServiceType) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	if _, dup := registry[st.Name]; dup {
+		panic("types: Register called twice for service " + st.Name)
+	}
+	st.Secrets = append(st.Secrets, secretFields("", reflect.TypeOf(st.New()))...)
+	registry[st.Name] = st
+}
+
+// Collect the keys of the fields tagged secret:"true" of a struct and of the structs it holds.
+func secretFields(path string, t reflect.Type) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	secrets := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Tag.Get("secret") == "true" {
+			secrets = append(secrets, path+f.Name)
+			continue
+		}
+		secrets = append(secrets, secretFields(path+f.Name+"/", f.Type)...)
+	}
+	return secrets
+}
+
+// Lookup returns the type registered for a service directory.
+func Lookup(name string) (ServiceType, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	st, ok := registry[name]
+	return st, ok
+}
+
+// Services returns every registered service type, sorted by name.
+func Services() []ServiceType {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	services := make([]ServiceType, 0, len(registry))
+	for _, st := range registry {
+		services = append(services, st)
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+	return services
+}
@@ -24,5 +24,6 @@ const (
 type RegistryInfo struct {
 	Host      string
 	Port      int
-	Type      string
+	// Type is the kind of registry, only Consul is supported.
+	Type      string `enum:"consul"`
 }
\ No newline at end of file
@@ -0,0 +1,49 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package types
+
+import "testing"
+
+func TestRegisteredServices(t *testing.T) {
+	names := []string{}
+	for _, st := range Services() {
+		names = append(names, st.Name)
+	}
+	if len(names) != 2 || names[0] != "EdgeX_Core_Command" || names[1] != "EdgeX_Core_Data" {
+		t.Fatalf("unexpected services %v", names)
+	}
+
+	st, ok := Lookup("EdgeX_Core_Data")
+	if !ok {
+		t.Fatal("EdgeX_Core_Data is not registered")
+	}
+	if _, ok := st.New().(*EdgeX_Core_Data); !ok {
+		t.Errorf("unexpected target %T", st.New())
+	}
+	if len(st.Secrets) != 1 || st.Secrets[0] != "Database/Password" {
+		t.Errorf("unexpected secrets %v", st.Secrets)
+	}
+	if _, ok := Lookup("edgex-core-data"); ok {
+		t.Error("V1 services must not be registered")
+	}
+}
+
+func TestRegisterTwice(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a service twice must panic")
+		}
+	}()
+	Register(ServiceType{Name: "EdgeX_Core_Data", New: func() interface{} { return &EdgeX_Core_Data{} }})
+}
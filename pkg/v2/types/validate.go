@@ -0,0 +1,244 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package types
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ValidationError describes a key of a configuration which does not match the struct of its service.
+type ValidationError struct {
+	// Key is the dotted path of the key, e.g. Service.Port.
+	Key string
+	// Reason tells what is wrong with the key.
+	Reason string
+}
+
+func (e ValidationError) Error() string {
+	return e.Key + ": " + e.Reason
+}
+
+// Resolved is a value set after the configuration was parsed, by an environment override, a
+// decryption or an interpolation. Having lost its TOML type, it matches a field when it parses
+// to the kind of that field.
+type Resolved string
+
+// Validate checks a configuration parsed from TOML against the struct of its service, target being
+// a pointer to that struct. It reports unknown keys, required sections missing from the
+// configuration, values of the wrong type and string values not listed in the enum tag of their field.
+func Validate(config map[string]interface{}, target interface{}, requiredSections []string) []ValidationError {
+	t := reflect.TypeOf(target)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	errs := []ValidationError{}
+	for _, section := range requiredSections {
+		if lookupKey(config, section) == "" {
+			errs = append(errs, ValidationError{Key: section, Reason: "missing required section"})
+		}
+	}
+	errs = append(errs, validateStruct("", config, t)...)
+
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Key < errs[j].Key })
+	return errs
+}
+
+// Find the key of a map matching a field name, ignoring case like the decoders of the services do.
+func lookupKey(m map[string]interface{}, name string) string {
+	for k := range m {
+		if strings.EqualFold(k, name) {
+			return k
+		}
+	}
+	return ""
+}
+
+func validateStruct(path string, m map[string]interface{}, t reflect.Type) []ValidationError {
+	errs := []ValidationError{}
+	for k, v := range m {
+		f, ok := t.FieldByNameFunc(func(name string) bool { return strings.EqualFold(name, k) })
+		if !ok {
+			errs = append(errs, ValidationError{Key: path + k, Reason: "unknown key"})
+			continue
+		}
+		errs = append(errs, validateValue(path+k, v, f.Type, f.Tag.Get("enum"))...)
+	}
+	return errs
+}
+
+func validateValue(path string, v interface{}, t reflect.Type, enum string) []ValidationError {
+	if r, ok := v.(Resolved); ok {
+		return validateResolved(path, string(r), t, enum)
+	}
+	wrongType := []ValidationError{{Key: path, Reason: fmt.Sprintf("expected %s, got %T", t.Kind(), v)}}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return wrongType
+		}
+		return validateStruct(path+".", m, t)
+	case reflect.Map:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return wrongType
+		}
+		errs := []ValidationError{}
+		for k, sv := range m {
+			errs = append(errs, validateValue(path+"."+k, sv, t.Elem(), "")...)
+		}
+		return errs
+	case reflect.Slice:
+		l, ok := v.([]interface{})
+		if !ok {
+			return wrongType
+		}
+		errs := []ValidationError{}
+		for i, sv := range l {
+			errs = append(errs, validateValue(fmt.Sprintf("%s.%d", path, i), sv, t.Elem(), "")...)
+		}
+		return errs
+	case reflect.String:
+		s, ok := v.(string)
+		if !ok {
+			return wrongType
+		}
+		if enum != "" && !inEnum(s, enum) {
+			return []ValidationError{{Key: path, Reason: fmt.Sprintf("%q is not one of %s", s, enum)}}
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch v.(type) {
+		case int, int64:
+		default:
+			return wrongType
+		}
+	case reflect.Float32, reflect.Float64:
+		switch v.(type) {
+		case int, int64, float64:
+		default:
+			return wrongType
+		}
+	case reflect.Bool:
+		if _, ok := v.(bool); !ok {
+			return wrongType
+		}
+	}
+	return nil
+}
+
+func validateResolved(path string, s string, t reflect.Type, enum string) []ValidationError {
+	var err error
+	switch t.Kind() {
+	case reflect.String:
+		if enum != "" && !inEnum(s, enum) {
+			return []ValidationError{{Key: path, Reason: fmt.Sprintf("%q is not one of %s", s, enum)}}
+		}
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		_, err = strconv.ParseInt(s, 10, t.Bits())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		_, err = strconv.ParseUint(s, 10, t.Bits())
+	case reflect.Float32, reflect.Float64:
+		_, err = strconv.ParseFloat(s, t.Bits())
+	case reflect.Bool:
+		_, err = strconv.ParseBool(s)
+	default:
+		return []ValidationError{{Key: path, Reason: fmt.Sprintf("expected %s, got %q", t.Kind(), s)}}
+	}
+	if err != nil {
+		return []ValidationError{{Key: path, Reason: fmt.Sprintf("expected %s, got %q", t.Kind(), s)}}
+	}
+	return nil
+}
+
+// TypeValues converts the string values of a configuration, as the K/V store holds them, to the kinds
+// of their fields in the struct of its service, target being a pointer to that struct. Values which
+// do not parse to the kind of their field and keys the struct does not have are left as they are.
+func TypeValues(config map[string]interface{}, target interface{}) {
+	t := reflect.TypeOf(target)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	typeStruct(config, t)
+}
+
+func typeStruct(m map[string]interface{}, t reflect.Type) {
+	for k, v := range m {
+		if f, ok := t.FieldByNameFunc(func(name string) bool { return strings.EqualFold(name, k) }); ok {
+			m[k] = typeValue(v, f.Type)
+		}
+	}
+}
+
+func typeValue(v interface{}, t reflect.Type) interface{} {
+	switch t.Kind() {
+	case reflect.Struct:
+		if m, ok := v.(map[string]interface{}); ok {
+			typeStruct(m, t)
+		}
+		return v
+	case reflect.Map:
+		if m, ok := v.(map[string]interface{}); ok {
+			for k, sv := range m {
+				m[k] = typeValue(sv, t.Elem())
+			}
+		}
+		return v
+	case reflect.Slice:
+		if l, ok := v.([]interface{}); ok {
+			for i, sv := range l {
+				l[i] = typeValue(sv, t.Elem())
+			}
+		}
+		return v
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		return v
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i
+		}
+	case reflect.Float32, reflect.Float64:
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+	}
+	return v
+}
+
+// Check a value against the comma-separated values of an enum tag.
+func inEnum(s string, enum string) bool {
+	for _, e := range strings.Split(enum, ",") {
+		if s == e {
+			return true
+		}
+	}
+	return false
+}
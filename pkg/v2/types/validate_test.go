@@ -0,0 +1,136 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package types
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pelletier/go-toml"
+)
+
+func TestValidateShippedConfiguration(t *testing.T) {
+	for path, service := range map[string]string{
+		"../toml/EdgeX_Core_Command/configuration.toml": "EdgeX_Core_Command",
+		"../toml/EdgeX_Core_Data/configuration.toml":    "EdgeX_Core_Data",
+	} {
+		tree, err := toml.LoadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		st, ok := Lookup(service)
+		if !ok {
+			t.Fatalf("%s is not registered", service)
+		}
+		if errs := st.Validate(tree.ToMap()); len(errs) != 0 {
+			t.Errorf("%s: %v", path, errs)
+		}
+	}
+}
+
+func TestValidateReportsProblems(t *testing.T) {
+	tree, err := toml.Load(`
+[Service]
+Port = '48082'
+Bogus = 1
+
+[Registry]
+Type = 'eureka'
+
+[Logging]
+EnableRemote = false
+
+[MetaData]
+DeviceURL = 'http://localhost:48081/api/v1/device'
+
+[Clients]
+  [Clients.Metadata]
+  Host = 1
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []ValidationError{
+		{Key: "Clients.Metadata.Host", Reason: "expected string, got int64"},
+		{Key: "Database", Reason: "missing required section"},
+		{Key: "Registry.Type", Reason: `"eureka" is not one of consul`},
+		{Key: "Service.Bogus", Reason: "unknown key"},
+		{Key: "Service.Port", Reason: "expected int, got string"},
+	}
+	st, _ := Lookup("EdgeX_Core_Data")
+	if errs := st.Validate(tree.ToMap()); !reflect.DeepEqual(errs, expected) {
+		t.Errorf("bad errors: %#v", errs)
+	}
+}
+
+func TestValidateResolvedValues(t *testing.T) {
+	config := map[string]interface{}{
+		"Service":  map[string]interface{}{"Port": Resolved("48082"), "Timeout": Resolved("5s"), "Host": Resolved("localhost")},
+		"Registry": map[string]interface{}{"Type": Resolved("eureka")},
+		"Logging":  map[string]interface{}{"EnableRemote": Resolved("true")},
+		"Clients":  Resolved("none"),
+	}
+
+	expected := []ValidationError{
+		{Key: "Clients", Reason: `expected map, got "none"`},
+		{Key: "Registry.Type", Reason: `"eureka" is not one of consul`},
+		{Key: "Service.Timeout", Reason: `expected int, got "5s"`},
+	}
+	errs := Validate(config, &struct {
+		Service struct {
+			Host    string
+			Port    int
+			Timeout int
+		}
+		Registry struct {
+			Type string `enum:"consul"`
+		}
+		Logging struct {
+			EnableRemote bool
+		}
+		Clients map[string]struct{ Host string }
+	}{}, nil)
+	if !reflect.DeepEqual(errs, expected) {
+		t.Errorf("bad errors: %#v", errs)
+	}
+}
+
+func TestTypeValues(t *testing.T) {
+	config := map[string]interface{}{
+		"Service": map[string]interface{}{"Port": "48082", "Host": "48080", "Timeout": "5s", "Bogus": "1"},
+		"Logging": map[string]interface{}{"EnableRemote": "true"},
+		"Clients": map[string]interface{}{"Metadata": map[string]interface{}{"Port": "007"}},
+	}
+	TypeValues(config, &struct {
+		Service struct {
+			Host    string
+			Port    int
+			Timeout int
+		}
+		Logging struct {
+			EnableRemote bool
+		}
+		Clients map[string]struct{ Port int }
+	}{})
+
+	expected := map[string]interface{}{
+		"Service": map[string]interface{}{"Port": int64(48082), "Host": "48080", "Timeout": "5s", "Bogus": "1"},
+		"Logging": map[string]interface{}{"EnableRemote": true},
+		"Clients": map[string]interface{}{"Metadata": map[string]interface{}{"Port": int64(7)}},
+	}
+	if !reflect.DeepEqual(config, expected) {
+		t.Errorf("expected %v, got %v", expected, config)
+	}
+}
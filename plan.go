@@ -0,0 +1,184 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg"
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg/store"
+)
+
+// What a seed does to a single key.
+type planAction int
+
+const (
+	planKeep planAction = iota
+	planAdd
+	planChange
+	planDelete
+)
+
+// Symbols used when printing a plan, in the style of Terraform.
+var planSymbols = map[planAction]string{
+	planKeep:   "=",
+	planAdd:    "+",
+	planChange: "~",
+	planDelete: "-",
+}
+
+// One key of a plan with its current and desired value.
+type planEntry struct {
+	Key    string
+	Action planAction
+	Old    string
+	New    string
+}
+
+// Read the configuration of every service, V2 services first, matching the order in which they are seeded.
+// The environment overrides matching no service are warned about.
+func readServices(profile string, coreConfig pkg.CoreConfig) ([]*serviceConfig, error) {
+	v2Services, err := readV2ConfigFromPath(profile, coreConfig)
+	if err != nil {
+		return nil, err
+	}
+	services, err := readConfigFromPath(profile, coreConfig)
+	if err != nil {
+		return nil, err
+	}
+	services = append(v2Services, services...)
+	warnUnmatchedOverrides(readEnvOverrides(), services)
+	return services, nil
+}
+
+// Flatten the configuration of services to full Consul keys.
+func flattenServices(services []*serviceConfig) pkg.ConfigProperties {
+	desired := pkg.ConfigProperties{}
+	for _, service := range services {
+		for k, v := range service.Props {
+			desired[service.Prefix+k] = v
+		}
+	}
+	return desired
+}
+
+// Flatten the configuration of every service to full Consul keys.
+func desiredConfig(profile string, coreConfig pkg.CoreConfig) (pkg.ConfigProperties, error) {
+	services, err := readServices(profile, coreConfig)
+	if err != nil {
+		return nil, err
+	}
+	return flattenServices(services), nil
+}
+
+// Read every key stored under the global prefix in the K/V store.
+func storedConfig(coreConfig pkg.CoreConfig, s store.ConfigStore) (pkg.ConfigProperties, error) {
+	values, err := s.List(coreConfig.GlobalPrefix + "/")
+	if err != nil {
+		return nil, err
+	}
+
+	stored := pkg.ConfigProperties{}
+	for key, value := range values {
+		// Skip the folder keys created by the Consul UI.
+		if !strings.HasSuffix(key, "/") {
+			stored[key] = string(value)
+		}
+	}
+	return stored, nil
+}
+
+// Compare the desired keys with the stored ones. Stored keys that are not desired are only
+// deleted when prune is set, otherwise they are left alone. Entries are sorted by key.
+func buildPlan(desired, stored pkg.ConfigProperties, prune bool) []planEntry {
+	plan := []planEntry{}
+
+	for k, v := range desired {
+		old, ok := stored[k]
+		switch {
+		case !ok:
+			plan = append(plan, planEntry{Key: k, Action: planAdd, New: v})
+		case old != v:
+			plan = append(plan, planEntry{Key: k, Action: planChange, Old: old, New: v})
+		default:
+			plan = append(plan, planEntry{Key: k, Action: planKeep, Old: old, New: v})
+		}
+	}
+	for k, v := range stored {
+		if _, ok := desired[k]; ok {
+			continue
+		}
+		if prune {
+			plan = append(plan, planEntry{Key: k, Action: planDelete, Old: v})
+		} else {
+			plan = append(plan, planEntry{Key: k, Action: planKeep, Old: v, New: v})
+		}
+	}
+
+	sort.Slice(plan, func(i, j int) bool { return plan[i].Key < plan[j].Key })
+	return plan
+}
+
+// Print a plan one key per line, followed by a summary of the changes.
+func printPlan(w io.Writer, plan []planEntry) {
+	counts := map[planAction]int{}
+	for _, e := range plan {
+		counts[e.Action]++
+		printPlanEntry(w, e)
+	}
+	fmt.Fprintf(w, "\nPlan: %d to add, %d to change, %d to delete, %d unchanged.\n",
+		counts[planAdd], counts[planChange], counts[planDelete], counts[planKeep])
+}
+
+func printPlanEntry(w io.Writer, e planEntry) {
+	switch e.Action {
+	case planAdd:
+		fmt.Fprintf(w, "  %s %s = %q\n", planSymbols[e.Action], e.Key, secrets.Redact(e.Key, e.New))
+	case planChange:
+		fmt.Fprintf(w, "  %s %s = %q -> %q\n", planSymbols[e.Action], e.Key,
+			secrets.Redact(e.Key, e.Old), secrets.Redact(e.Key, e.New))
+	case planDelete:
+		fmt.Fprintf(w, "  %s %s = %q\n", planSymbols[e.Action], e.Key, secrets.Redact(e.Key, e.Old))
+	default:
+		fmt.Fprintf(w, "  %s %s\n", planSymbols[e.Action], e.Key)
+	}
+}
+
+// Whether a seed removes stored keys missing from the config files: a reset clears the whole
+// global prefix, while a sync only deletes them when pruning.
+func isPruning(coreConfig pkg.CoreConfig) bool {
+	if coreConfig.IsSync {
+		return coreConfig.IsPrune
+	}
+	return coreConfig.IsReset
+}
+
+// Print what a seed would write to the K/V store without writing anything.
+func planConfig(profile string, coreConfig pkg.CoreConfig, s store.ConfigStore) error {
+	desired, err := desiredConfig(profile, coreConfig)
+	if err != nil {
+		return err
+	}
+	stored, err := storedConfig(coreConfig, s)
+	if err != nil {
+		return err
+	}
+
+	printPlan(os.Stdout, buildPlan(desired, stored, isPruning(coreConfig)))
+	return nil
+}
@@ -0,0 +1,72 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg"
+)
+
+func TestBuildPlan(t *testing.T) {
+	desired := pkg.ConfigProperties{
+		"config/svc/Host": "localhost",
+		"config/svc/Port": "48080",
+		"config/svc/New":  "value",
+	}
+	stored := pkg.ConfigProperties{
+		"config/svc/Host": "localhost",
+		"config/svc/Port": "48081",
+		"config/svc/Old":  "stale",
+	}
+
+	expected := []planEntry{
+		{Key: "config/svc/Host", Action: planKeep, Old: "localhost", New: "localhost"},
+		{Key: "config/svc/New", Action: planAdd, New: "value"},
+		{Key: "config/svc/Old", Action: planDelete, Old: "stale"},
+		{Key: "config/svc/Port", Action: planChange, Old: "48081", New: "48080"},
+	}
+	if actual := buildPlan(desired, stored, true); !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("bad plan with prune: %#v", actual)
+	}
+
+	expected[2] = planEntry{Key: "config/svc/Old", Action: planKeep, Old: "stale", New: "stale"}
+	if actual := buildPlan(desired, stored, false); !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("bad plan without prune: %#v", actual)
+	}
+}
+
+func TestPrintPlan(t *testing.T) {
+	plan := []planEntry{
+		{Key: "config/svc/New", Action: planAdd, New: "value"},
+		{Key: "config/svc/Port", Action: planChange, Old: "48081", New: "48080"},
+	}
+
+	var buf bytes.Buffer
+	printPlan(&buf, plan)
+
+	out := buf.String()
+	for _, line := range []string{
+		`  + config/svc/New = "value"`,
+		`  ~ config/svc/Port = "48081" -> "48080"`,
+		"Plan: 1 to add, 1 to change, 0 to delete, 0 unchanged.",
+	} {
+		if !strings.Contains(out, line) {
+			t.Errorf("missing %q in:\n%s", line, out)
+		}
+	}
+}
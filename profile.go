@@ -0,0 +1,76 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg/layer"
+	"github.com/pelletier/go-toml"
+)
+
+// Load the layered configuration files of a service directory and deep-merge them in order.
+// Files missing from the directory are skipped. It returns the merged tree, the file every
+// flattened key was last set by and the paths of the files read.
+func readV2Layers(dir string, files []string) (map[string]interface{}, map[string]string, []string, error) {
+	merged := map[string]interface{}{}
+	origins := map[string]string{}
+	paths := []string{}
+
+	for _, file := range files {
+		path := filepath.Join(dir, file)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+
+		contents, err := readConfigFile(path)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		config, err := toml.LoadBytes(contents)
+		if err != nil {
+			return nil, nil, nil, withExitCode(exitParse, fmt.Errorf("could not load configuration file (%s): %v", path, err.Error()))
+		}
+		m := config.ToMap()
+
+		kvs, err := traverse("", m)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		for _, kv := range kvs {
+			origins[kv.Key] = file
+		}
+		layer.Merge(merged, m)
+		paths = append(paths, path)
+	}
+	return merged, origins, paths, nil
+}
+
+// Print the layer every key of a service came from, sorted by key.
+func printLayers(w io.Writer, service *serviceConfig) {
+	keys := []string{}
+	for k := range service.Props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fmt.Fprintf(w, "layers of %s:\n", service.Dir)
+	for _, k := range keys {
+		fmt.Fprintf(w, "  %s = %q (%s)\n", k, secrets.Redact(service.Prefix+k, service.Props[k]), service.Layers[k])
+	}
+}
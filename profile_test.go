@@ -0,0 +1,150 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg"
+)
+
+func TestReadV2ConfigLayers(t *testing.T) {
+	root, err := ioutil.TempDir("", "profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	files := map[string]string{
+		"configuration.toml": `
+[Service]
+Host = 'localhost'
+Port = 48080
+[Clients.Metadata]
+Host = 'localhost'
+Port = 48081
+[[Devices]]
+Name = 'first'
+[[Devices]]
+Name = 'second'
+`,
+		"configuration-docker.toml": `
+[Service]
+Host = 'edgex-core-data'
+[Clients.Metadata]
+Host = 'edgex-core-metadata'
+`,
+		"configuration-arm64.toml": `
+[Service]
+Port = 48090
+[[Devices]]
+Name = 'arm'
+`,
+	}
+	if err := os.MkdirAll(filepath.Join(root, "Svc"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, contents := range files {
+		if err := ioutil.WriteFile(filepath.Join(root, "Svc", name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	coreConfig := pkg.CoreConfig{ConfigPathV2: root, GlobalPrefix: "config"}
+	services, err := readV2ConfigFromPath("docker, arm64", coreConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(services) != 1 || services[0].Dir != "Svc/" {
+		t.Fatalf("unexpected services %+v", services)
+	}
+
+	expected := pkg.ConfigProperties{
+		"Service/Host":          "edgex-core-data",
+		"Service/Port":          "48090",
+		"Clients/Metadata/Host": "edgex-core-metadata",
+		"Clients/Metadata/Port": "48081",
+		"Devices/0/Name":        "arm",
+	}
+	if !reflect.DeepEqual(services[0].Props, expected) {
+		t.Errorf("expected %v, got %v", expected, services[0].Props)
+	}
+
+	layers := map[string]string{
+		"Service/Host":          "configuration-docker.toml",
+		"Service/Port":          "configuration-arm64.toml",
+		"Clients/Metadata/Host": "configuration-docker.toml",
+		"Clients/Metadata/Port": "configuration.toml",
+		"Devices/0/Name":        "configuration-arm64.toml",
+	}
+	if !reflect.DeepEqual(services[0].Layers, layers) {
+		t.Errorf("expected layers %v, got %v", layers, services[0].Layers)
+	}
+
+	// Without profiles only the base file is read.
+	services, err = readV2ConfigFromPath("", coreConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if services[0].Props["Service/Host"] != "localhost" || services[0].Props["Devices/1/Name"] != "second" {
+		t.Errorf("unexpected base configuration %v", services[0].Props)
+	}
+}
+
+func TestDetermineConfigFile(t *testing.T) {
+	if file := determineConfigFile("docker,arm64"); file != "configuration-arm64.toml" {
+		t.Errorf("unexpected config file %s", file)
+	}
+}
+
+// The shipped docker profiles are overlays: every key they hold differs from the base file, and
+// the merged configuration matches the type of the service.
+func TestShippedDockerProfiles(t *testing.T) {
+	root := "pkg/v2/toml"
+	for _, service := range []string{"EdgeX_Core_Command", "EdgeX_Core_Data"} {
+		base, err := readTomlFile(filepath.Join(root, service, configDefault))
+		if err != nil {
+			t.Fatal(err)
+		}
+		docker, err := readTomlFile(filepath.Join(root, service, "configuration-docker.toml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range docker {
+			if base[k] == v {
+				t.Errorf("%s: %s repeats the base value %q", service, k, v)
+			}
+		}
+	}
+
+	services, err := readV2ConfigFromPath("docker", pkg.CoreConfig{ConfigPathV2: root, GlobalPrefix: "config"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, service := range services {
+		if service.Dir != "EdgeX_Core_Data/" {
+			continue
+		}
+		for k, v := range map[string]string{"Service/Host": "edgex-core-data", "Service/Port": "48080",
+			"Logging/File": "./logs/edgex-core-data.log", "Database/Name": "metadb"} {
+			if service.Props[k] != v {
+				t.Errorf("%s: expected %q, got %q", k, v, service.Props[k])
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg"
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg/store"
+)
+
+// Restore the keys under the global prefix to a saved snapshot. Keys which are not in the snapshot
+// are deleted. The current tree is saved as a snapshot first, so a rollback can be rolled back too.
+func rollbackConfig(id string, coreConfig pkg.CoreConfig, s store.ConfigStore) error {
+	if !isSnapshotEnabled(coreConfig) {
+		return errors.New("snapshots are disabled, set SnapshotPath or SnapshotPrefix")
+	}
+
+	if id == "" {
+		ids, err := listSnapshots(coreConfig, s)
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("rollback needs --to <id>, saved snapshots: %s", strings.Join(ids, ", "))
+	}
+
+	snap, err := loadSnapshot(coreConfig, s, id)
+	if err != nil {
+		return err
+	}
+	if _, err := saveSnapshot(coreConfig, s); err != nil {
+		return err
+	}
+
+	stored, err := storedConfig(coreConfig, s)
+	if err != nil {
+		return err
+	}
+	if err := applyChanges(buildPlan(absoluteKeys(coreConfig.GlobalPrefix, snap.Pairs), stored, true), coreConfig, stored, s); err != nil {
+		return err
+	}
+	fmt.Println("Rolled back the globalPrefix(\"" + coreConfig.GlobalPrefix + "\") to snapshot " + snap.ID + ".")
+	return nil
+}
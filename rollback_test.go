@@ -0,0 +1,129 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg"
+)
+
+func TestRollbackToSnapshot(t *testing.T) {
+	s := newMemoryStore(map[string]string{
+		"config/svc/Host": "localhost",
+		"config/svc/Port": "48080",
+	})
+	coreConfig := pkg.CoreConfig{GlobalPrefix: "config", SnapshotPrefix: "config-snapshots"}
+
+	snap, err := saveSnapshot(coreConfig, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A bad seed.
+	s.data["config/svc/Port"] = "1"
+	s.data["config/svc/Extra"] = "value"
+	delete(s.data, "config/svc/Host")
+
+	if err := rollbackConfig(snap.ID, coreConfig, s); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := storedConfig(coreConfig, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := pkg.ConfigProperties{"config/svc/Host": "localhost", "config/svc/Port": "48080"}
+	if !reflect.DeepEqual(stored, expected) {
+		t.Errorf("bad rollback: %#v", stored)
+	}
+	if ids, _ := listSnapshots(coreConfig, s); len(ids) != 2 {
+		t.Errorf("the snapshot taken before the rollback must not replace the one restored: %v", ids)
+	}
+}
+
+func TestSnapshotIDsAreUnique(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshots")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(f func() time.Time) { snapshotClock = f }(snapshotClock)
+	snapshotClock = func() time.Time { return time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC) }
+
+	coreConfig := pkg.CoreConfig{GlobalPrefix: "config", SnapshotPath: dir}
+	s := newMemoryStore(map[string]string{"config/svc/Port": "48080"})
+	for i := 0; i < 3; i++ {
+		if _, err := saveSnapshot(coreConfig, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ids, err := listSnapshots(coreConfig, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"20180101T000000.000000000Z", "20180101T000000.000000000Z-1", "20180101T000000.000000000Z-2"}
+	if !reflect.DeepEqual(ids, expected) {
+		t.Errorf("expected %v, got %v", expected, ids)
+	}
+	info, err := os.Stat(filepath.Join(dir, ids[0]+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("snapshot files must only be readable by their owner, got %v", info.Mode().Perm())
+	}
+}
+
+func TestSnapshotFilesLimit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshots")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	coreConfig := pkg.CoreConfig{GlobalPrefix: "config", SnapshotPath: dir, SnapshotLimit: 1}
+	s := newMemoryStore(nil)
+
+	for _, id := range []string{"20180101T000000Z", "20180102T000000Z"} {
+		if err := writeSnapshotFile(dir+"/"+id+".json", &snapshot{ID: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	snap, err := saveSnapshot(coreConfig, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids, err := listSnapshots(coreConfig, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ids, []string{snap.ID}) {
+		t.Errorf("expected only the new snapshot, got %v", ids)
+	}
+}
+
+func TestSnapshotPrefixUnderGlobalPrefix(t *testing.T) {
+	coreConfig := pkg.CoreConfig{GlobalPrefix: "config", SnapshotPrefix: "config/snapshots"}
+	if _, err := saveSnapshot(coreConfig, newMemoryStore(nil)); err == nil {
+		t.Error("expected an error")
+	}
+}
@@ -0,0 +1,82 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg"
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg/secret"
+)
+
+// Tells which keys hold secrets, to keep their values out of the output. main adds the SecretPatterns.
+var secrets = &secret.Matcher{}
+
+// Whether secret values are written to Vault, the K/V store only holding references to them.
+func isVaultEnabled(coreConfig pkg.CoreConfig) bool {
+	return coreConfig.SecretStore == secret.Vault
+}
+
+// The Vault store of the configuration. The token defaults to the VAULT_TOKEN environment variable.
+func newVaultStore(coreConfig pkg.CoreConfig) *secret.VaultStore {
+	token := coreConfig.VaultToken
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+	return secret.NewVaultStore(coreConfig.VaultAddress, token, coreConfig.VaultMount, nil)
+}
+
+// The Vault path holding the secrets of a service.
+func vaultPath(service *serviceConfig, coreConfig pkg.CoreConfig) string {
+	return coreConfig.VaultPath + "/" + strings.TrimSuffix(service.Dir, "/")
+}
+
+// Replace the secret values of a service with references to Vault, keeping the values in
+// service.Secrets until writeSecrets. Nothing is moved unless Vault is enabled.
+func moveSecrets(service *serviceConfig, coreConfig pkg.CoreConfig) {
+	if !isVaultEnabled(coreConfig) {
+		return
+	}
+
+	vault := newVaultStore(coreConfig)
+	for k, v := range service.Props {
+		if !secrets.IsSecret(service.Prefix + k) {
+			continue
+		}
+		if service.Secrets == nil {
+			service.Secrets = pkg.ConfigProperties{}
+		}
+		service.Secrets[k] = v
+		service.Props[k] = vault.Reference(vaultPath(service, coreConfig), k)
+	}
+}
+
+// Write the secrets moved out of every service to Vault, before the references to them are seeded.
+func writeSecrets(services []*serviceConfig, coreConfig pkg.CoreConfig) error {
+	if !isVaultEnabled(coreConfig) {
+		return nil
+	}
+
+	vault := newVaultStore(coreConfig)
+	for _, service := range services {
+		if len(service.Secrets) == 0 {
+			continue
+		}
+		if err := vault.Put(vaultPath(service, coreConfig), service.Secrets); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg"
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg/secret"
+)
+
+func TestPrintPlanRedactsSecrets(t *testing.T) {
+	defer func(m *secret.Matcher) { secrets = m }(secrets)
+	var err error
+	secrets, err = secret.NewMatcher([]string{"(?i)password"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	printPlan(&out, buildPlan(
+		pkg.ConfigProperties{"config/EdgeX_Core_Data/Database/Password": "new", "config/edgex-core-data;go/MongoDBPassword": "p4ss", "config/svc/Host": "mongo"},
+		pkg.ConfigProperties{"config/EdgeX_Core_Data/Database/Password": "old"},
+		false))
+
+	if strings.Contains(out.String(), "p4ss") || strings.Contains(out.String(), "old") || strings.Contains(out.String(), "new") {
+		t.Errorf("secrets in the output:\n%s", out.String())
+	}
+	for _, line := range []string{
+		"  ~ config/EdgeX_Core_Data/Database/Password = \"<redacted>\" -> \"<redacted>\"",
+		"  + config/edgex-core-data;go/MongoDBPassword = \"<redacted>\"",
+		"  + config/svc/Host = \"mongo\"",
+	} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("output is missing %q:\n%s", line, out.String())
+		}
+	}
+}
+
+func TestMoveSecretsToVault(t *testing.T) {
+	written := map[string]map[string]string{}
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Data map[string]string `json:"data"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		written[r.URL.Path] = req.Data
+	}))
+	defer stub.Close()
+
+	coreConfig := pkg.CoreConfig{SecretStore: secret.Vault, VaultAddress: stub.URL, VaultToken: "root", VaultMount: "secret", VaultPath: "edgex"}
+	service := &serviceConfig{
+		Dir:    "EdgeX_Core_Data/",
+		Prefix: "config/EdgeX_Core_Data/",
+		Props:  pkg.ConfigProperties{"Database/Password": "p4ss", "Database/Host": "mongo"},
+	}
+	plain := &serviceConfig{Dir: "svc/", Prefix: "config/svc/", Props: pkg.ConfigProperties{"Host": "localhost"}}
+
+	moveSecrets(service, coreConfig)
+	moveSecrets(plain, coreConfig)
+	if err := writeSecrets([]*serviceConfig{service, plain}, coreConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	if service.Props["Database/Password"] != "vault:secret/edgex/EdgeX_Core_Data#Database/Password" || service.Props["Database/Host"] != "mongo" {
+		t.Errorf("unexpected properties %v", service.Props)
+	}
+	if len(written) != 1 || written["/v1/secret/data/edgex/EdgeX_Core_Data"]["Database/Password"] != "p4ss" {
+		t.Errorf("unexpected secrets written %v", written)
+	}
+}
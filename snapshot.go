@@ -0,0 +1,226 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg"
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg/store"
+)
+
+// Layout of the snapshot ids, which sort in the order the snapshots were taken. Nanoseconds keep
+// the snapshot taken before a rollback from replacing the one being restored.
+const snapshotIDFormat = "20060102T150405.000000000Z"
+
+// Hook the clock of the snapshots for the tests.
+var snapshotClock = time.Now
+
+// Saved copy of the keys stored under a global prefix.
+type snapshot struct {
+	ID      string
+	Prefix  string
+	Created time.Time
+	// Pairs maps keys relative to Prefix to their values.
+	Pairs pkg.ConfigProperties
+}
+
+// Take a snapshot of stored keys, which are relative to the global prefix afterwards.
+func newSnapshot(globalPrefix string, stored pkg.ConfigProperties) *snapshot {
+	created := snapshotClock().UTC()
+	return &snapshot{
+		ID:      created.Format(snapshotIDFormat),
+		Prefix:  globalPrefix,
+		Created: created,
+		Pairs:   relativeKeys(globalPrefix, stored),
+	}
+}
+
+// Strip the global prefix from full keys.
+func relativeKeys(globalPrefix string, props pkg.ConfigProperties) pkg.ConfigProperties {
+	relative := pkg.ConfigProperties{}
+	for k, v := range props {
+		relative[strings.TrimPrefix(k, globalPrefix+"/")] = v
+	}
+	return relative
+}
+
+// Put the global prefix in front of relative keys.
+func absoluteKeys(globalPrefix string, props pkg.ConfigProperties) pkg.ConfigProperties {
+	absolute := pkg.ConfigProperties{}
+	for k, v := range props {
+		absolute[globalPrefix+"/"+k] = v
+	}
+	return absolute
+}
+
+func readSnapshotFile(path string) (*snapshot, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	snap := &snapshot{}
+	if err := json.Unmarshal(contents, snap); err != nil {
+		return nil, fmt.Errorf("could not parse snapshot file (%s): %v", path, err)
+	}
+	return snap, nil
+}
+
+func writeSnapshotFile(path string, snap *snapshot) error {
+	contents, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	// Snapshots hold every value, secrets included.
+	return ioutil.WriteFile(path, contents, 0600)
+}
+
+// Check whether snapshots are kept, in SnapshotPath or else under SnapshotPrefix.
+func isSnapshotEnabled(coreConfig pkg.CoreConfig) bool {
+	return coreConfig.SnapshotPath != "" || coreConfig.SnapshotPrefix != ""
+}
+
+// Save a snapshot before the keys under the global prefix are changed, when snapshots are kept.
+func saveSnapshotIfEnabled(coreConfig pkg.CoreConfig, s store.ConfigStore) error {
+	if !isSnapshotEnabled(coreConfig) {
+		return nil
+	}
+	_, err := saveSnapshot(coreConfig, s)
+	return err
+}
+
+// Save a snapshot of the keys under the global prefix before they are changed,
+// then drop the oldest snapshots beyond SnapshotLimit.
+func saveSnapshot(coreConfig pkg.CoreConfig, s store.ConfigStore) (*snapshot, error) {
+	stored, err := storedConfig(coreConfig, s)
+	if err != nil {
+		return nil, err
+	}
+	snap := newSnapshot(coreConfig.GlobalPrefix, stored)
+
+	// A clock too coarse for nanoseconds could still repeat an id, never replace a snapshot.
+	ids, err := listSnapshots(coreConfig, s)
+	if err != nil {
+		return nil, err
+	}
+	base := snap.ID
+	for i := 1; containsString(ids, snap.ID); i++ {
+		snap.ID = base + "-" + strconv.Itoa(i)
+	}
+
+	if coreConfig.SnapshotPath != "" {
+		err = writeSnapshotFile(filepath.Join(coreConfig.SnapshotPath, snap.ID+".json"), snap)
+	} else {
+		if strings.HasPrefix(coreConfig.SnapshotPrefix+"/", coreConfig.GlobalPrefix+"/") {
+			return nil, errors.New("the SnapshotPrefix must not be under the GlobalPrefix")
+		}
+		var contents []byte
+		if contents, err = json.Marshal(snap); err == nil {
+			err = s.Put(coreConfig.SnapshotPrefix+"/"+snap.ID, contents)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("Saved snapshot %s of %d keys under the globalPrefix(\"%s\").\n", snap.ID, len(snap.Pairs), coreConfig.GlobalPrefix)
+
+	if coreConfig.SnapshotLimit > 0 {
+		ids, err := listSnapshots(coreConfig, s)
+		if err != nil {
+			return nil, err
+		}
+		for len(ids) > coreConfig.SnapshotLimit {
+			if err := deleteSnapshot(coreConfig, s, ids[0]); err != nil {
+				return nil, err
+			}
+			ids = ids[1:]
+		}
+	}
+	return snap, nil
+}
+
+// Ids of the saved snapshots, oldest first.
+func listSnapshots(coreConfig pkg.CoreConfig, s store.ConfigStore) ([]string, error) {
+	ids := []string{}
+	if coreConfig.SnapshotPath != "" {
+		infos, err := ioutil.ReadDir(coreConfig.SnapshotPath)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		for _, info := range infos {
+			if !info.IsDir() && filepath.Ext(info.Name()) == ".json" {
+				ids = append(ids, strings.TrimSuffix(info.Name(), ".json"))
+			}
+		}
+	} else {
+		keys, err := s.Keys(coreConfig.SnapshotPrefix + "/")
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			ids = append(ids, strings.TrimPrefix(key, coreConfig.SnapshotPrefix+"/"))
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func loadSnapshot(coreConfig pkg.CoreConfig, s store.ConfigStore, id string) (*snapshot, error) {
+	if coreConfig.SnapshotPath != "" {
+		return readSnapshotFile(filepath.Join(coreConfig.SnapshotPath, id+".json"))
+	}
+
+	contents, ok, err := s.Get(coreConfig.SnapshotPrefix + "/" + id)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("snapshot %s not found", id)
+	}
+	snap := &snapshot{}
+	if err := json.Unmarshal(contents, snap); err != nil {
+		return nil, fmt.Errorf("could not parse snapshot %s: %v", id, err)
+	}
+	return snap, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+func deleteSnapshot(coreConfig pkg.CoreConfig, s store.ConfigStore, id string) error {
+	if coreConfig.SnapshotPath != "" {
+		return os.Remove(filepath.Join(coreConfig.SnapshotPath, id+".json"))
+	}
+	return s.Delete(coreConfig.SnapshotPrefix + "/" + id)
+}
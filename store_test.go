@@ -0,0 +1,99 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package main
+
+import (
+	"strings"
+
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg/store"
+)
+
+// In-memory ConfigStore for the tests. failTxn, when set, is called with the number of the
+// transaction (starting at 1) and can fail it before anything is applied.
+type memoryStore struct {
+	data    map[string]string
+	txns    int
+	failTxn func(n int) error
+}
+
+func newMemoryStore(data map[string]string) *memoryStore {
+	if data == nil {
+		data = map[string]string{}
+	}
+	return &memoryStore{data: data}
+}
+
+func (m *memoryStore) Put(key string, value []byte) error {
+	m.data[key] = string(value)
+	return nil
+}
+
+func (m *memoryStore) Get(key string) ([]byte, bool, error) {
+	value, ok := m.data[key]
+	return []byte(value), ok, nil
+}
+
+func (m *memoryStore) Keys(prefix string) ([]string, error) {
+	keys := []string{}
+	for k := range m.data {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+func (m *memoryStore) List(prefix string) (map[string][]byte, error) {
+	values := map[string][]byte{}
+	for k, v := range m.data {
+		if strings.HasPrefix(k, prefix) {
+			values[k] = []byte(v)
+		}
+	}
+	return values, nil
+}
+
+func (m *memoryStore) Delete(key string) error {
+	delete(m.data, key)
+	return nil
+}
+
+func (m *memoryStore) DeleteTree(prefix string) error {
+	for k := range m.data {
+		if strings.HasPrefix(k, prefix) {
+			delete(m.data, k)
+		}
+	}
+	return nil
+}
+
+func (m *memoryStore) Txn(ops []store.TxnOp) error {
+	m.txns++
+	if m.failTxn != nil {
+		if err := m.failTxn(m.txns); err != nil {
+			return err
+		}
+	}
+	for _, op := range ops {
+		switch op.Verb {
+		case store.TxnSet:
+			m.data[op.Key] = string(op.Value)
+		case store.TxnDelete:
+			delete(m.data, op.Key)
+		case store.TxnDeleteTree:
+			m.DeleteTree(op.Key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package main
+
+import (
+	"fmt"
+
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg"
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg/store"
+)
+
+// Bring the K/V store in line with the config files without clearing the global prefix first.
+// Only added and changed keys are written, so running services never see an empty tree.
+// Stored keys missing from the config files are deleted only when pruning, see isPruning.
+// With IsAtomic the changes are committed through transactions.
+func syncConfig(profile string, coreConfig pkg.CoreConfig, s store.ConfigStore) error {
+	services, err := readServices(profile, coreConfig)
+	if err != nil {
+		return err
+	}
+	if err := writeSecrets(services, coreConfig); err != nil {
+		return withExitCode(exitPartialWrite, err)
+	}
+	desired := flattenServices(services)
+	stored, err := storedConfig(coreConfig, s)
+	if err != nil {
+		return err
+	}
+
+	err = applyChanges(buildPlan(desired, stored, isPruning(coreConfig)), coreConfig, stored, s)
+	return withExitCode(exitPartialWrite, err)
+}
+
+// Apply a plan through transactions with IsAtomic, one key at a time otherwise.
+func applyChanges(plan []planEntry, coreConfig pkg.CoreConfig, stored pkg.ConfigProperties, s store.ConfigStore) error {
+	if coreConfig.IsAtomic {
+		return applyPlanAtomic(plan, coreConfig, stored, s)
+	}
+	return applyPlan(plan, s)
+}
+
+// Write the added and changed keys of a plan and delete the removed ones.
+func applyPlan(plan []planEntry, s store.ConfigStore) error {
+	written, deleted := 0, 0
+	for _, e := range plan {
+		switch e.Action {
+		case planAdd, planChange:
+			if err := s.Put(e.Key, []byte(e.New)); err != nil {
+				return err
+			}
+			fmt.Println("sync wrote key", e.Key)
+			written++
+		case planDelete:
+			if err := s.Delete(e.Key); err != nil {
+				return err
+			}
+			fmt.Println("sync deleted key", e.Key)
+			deleted++
+		}
+	}
+	fmt.Printf("Sync complete: %d written, %d deleted, %d unchanged.\n", written, deleted, len(plan)-written-deleted)
+	return nil
+}
@@ -0,0 +1,49 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApplyPlan(t *testing.T) {
+	s := newMemoryStore(map[string]string{
+		"config/svc/Host": "localhost",
+		"config/svc/Old":  "stale",
+		"config/svc/Port": "48081",
+	})
+
+	plan := []planEntry{
+		{Key: "config/svc/Host", Action: planKeep, Old: "localhost", New: "localhost"},
+		{Key: "config/svc/New", Action: planAdd, New: "value"},
+		{Key: "config/svc/Old", Action: planDelete, Old: "stale"},
+		{Key: "config/svc/Port", Action: planChange, Old: "48081", New: "48080"},
+	}
+	if err := applyPlan(plan, s); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"config/svc/Host": "localhost",
+		"config/svc/New":  "value",
+		"config/svc/Port": "48080",
+	}
+	if !reflect.DeepEqual(s.data, expected) {
+		t.Errorf("bad store: %#v", s.data)
+	}
+	if s.txns != 0 {
+		t.Errorf("keys must be deleted without a transaction each, got %d transactions", s.txns)
+	}
+}
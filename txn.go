@@ -0,0 +1,124 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg"
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg/store"
+)
+
+const (
+	// Consul rejects a transaction with more operations than this, the other stores allow at least as many.
+	maxTxnOps = 64
+
+	// Commit every service directory in its own transaction.
+	atomicScopeService = "service"
+	// Commit the whole seed as one transaction.
+	atomicScopeSeed = "seed"
+)
+
+// Split the changes of a plan into the batches that must succeed or fail together.
+// With the service scope there is one batch per service directory directly under the global prefix.
+func txnBatches(plan []planEntry, coreConfig pkg.CoreConfig) [][]store.TxnOp {
+	batches := [][]store.TxnOp{}
+	byService := map[string]int{}
+
+	for _, e := range plan {
+		var op store.TxnOp
+		switch e.Action {
+		case planAdd, planChange:
+			op = store.TxnOp{Verb: store.TxnSet, Key: e.Key, Value: []byte(e.New)}
+		case planDelete:
+			op = store.TxnOp{Verb: store.TxnDelete, Key: e.Key}
+		default:
+			continue
+		}
+
+		service := ""
+		if coreConfig.AtomicScope != atomicScopeSeed {
+			service = strings.SplitN(strings.TrimPrefix(e.Key, coreConfig.GlobalPrefix+"/"), "/", 2)[0]
+		}
+		i, ok := byService[service]
+		if !ok {
+			i = len(batches)
+			byService[service] = i
+			batches = append(batches, []store.TxnOp{})
+		}
+		batches[i] = append(batches[i], op)
+	}
+	return batches
+}
+
+// Write a batch in transactions of at most maxTxnOps operations. A batch that fits in one
+// transaction is committed atomically by the store. A larger batch is committed chunk by chunk;
+// when a chunk fails, the chunks already committed are reverted to the stored values, so the
+// keys of the batch are left either fully old or fully new.
+func commitTxn(s store.ConfigStore, ops []store.TxnOp, stored pkg.ConfigProperties) error {
+	committed := []store.TxnOp{}
+	for start := 0; start < len(ops); start += maxTxnOps {
+		end := start + maxTxnOps
+		if end > len(ops) {
+			end = len(ops)
+		}
+
+		if err := s.Txn(ops[start:end]); err != nil {
+			if rerr := revertTxn(s, committed, stored); rerr != nil {
+				return fmt.Errorf("%v; reverting %d committed operations failed: %v", err, len(committed), rerr)
+			}
+			return err
+		}
+		committed = append(committed, ops[start:end]...)
+	}
+	return nil
+}
+
+// Restore the stored value of every key touched by ops, deleting the keys that did not exist.
+func revertTxn(s store.ConfigStore, ops []store.TxnOp, stored pkg.ConfigProperties) error {
+	revert := []store.TxnOp{}
+	for _, op := range ops {
+		if old, ok := stored[op.Key]; ok {
+			revert = append(revert, store.TxnOp{Verb: store.TxnSet, Key: op.Key, Value: []byte(old)})
+		} else {
+			revert = append(revert, store.TxnOp{Verb: store.TxnDelete, Key: op.Key})
+		}
+	}
+
+	for start := 0; start < len(revert); start += maxTxnOps {
+		end := start + maxTxnOps
+		if end > len(revert) {
+			end = len(revert)
+		}
+		if err := s.Txn(revert[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Apply the changes of a plan through transactions, one batch at a time.
+// Batches committed before a failing one are kept.
+func applyPlanAtomic(plan []planEntry, coreConfig pkg.CoreConfig, stored pkg.ConfigProperties, s store.ConfigStore) error {
+	for _, ops := range txnBatches(plan, coreConfig) {
+		if err := commitTxn(s, ops, stored); err != nil {
+			return err
+		}
+		for _, op := range ops {
+			fmt.Println("txn", op.Verb, "key", op.Key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package main
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg"
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg/store"
+)
+
+func TestTxnBatches(t *testing.T) {
+	plan := []planEntry{
+		{Key: "config/a/Host", Action: planAdd, New: "localhost"},
+		{Key: "config/a/Port", Action: planKeep, Old: "1", New: "1"},
+		{Key: "config/b;docker/Port", Action: planChange, Old: "1", New: "2"},
+		{Key: "config/c/Old", Action: planDelete, Old: "stale"},
+	}
+
+	coreConfig := pkg.CoreConfig{GlobalPrefix: "config", AtomicScope: atomicScopeService}
+	if batches := txnBatches(plan, coreConfig); len(batches) != 3 {
+		t.Fatalf("expected 3 service batches, got %d", len(batches))
+	}
+
+	coreConfig.AtomicScope = atomicScopeSeed
+	batches := txnBatches(plan, coreConfig)
+	if len(batches) != 1 || len(batches[0]) != 3 {
+		t.Fatalf("expected 1 seed batch of 3 operations, got %#v", batches)
+	}
+	if batches[0][2].Verb != store.TxnDelete {
+		t.Errorf("expected a delete, got %s", batches[0][2].Verb)
+	}
+}
+
+func TestCommitTxnRevertsOnFailure(t *testing.T) {
+	s := newMemoryStore(map[string]string{"config/a/Key0": "old"})
+	stored := pkg.ConfigProperties{"config/a/Key0": "old"}
+
+	// Fail the second chunk of the seed.
+	s.failTxn = func(n int) error {
+		if n == 2 {
+			return errors.New("failed")
+		}
+		return nil
+	}
+
+	ops := []store.TxnOp{}
+	for i := 0; i < maxTxnOps+10; i++ {
+		ops = append(ops, store.TxnOp{Verb: store.TxnSet, Key: "config/a/Key" + strconv.Itoa(i), Value: []byte("new")})
+	}
+
+	if err := commitTxn(s, ops, stored); err == nil {
+		t.Fatal("expected an error")
+	}
+	if s.txns != 3 {
+		t.Errorf("expected 2 chunks and 1 revert, got %d transactions", s.txns)
+	}
+	if len(s.data) != 1 || s.data["config/a/Key0"] != "old" {
+		t.Errorf("store not reverted: %#v", s.data)
+	}
+}
@@ -0,0 +1,196 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg"
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg/store"
+	"github.com/edgexfoundry/core-config-seed-go/pkg/v2/client"
+	"github.com/edgexfoundry/core-config-seed-go/pkg/v2/types"
+)
+
+// A field of a V2 service whose stored value differs from its source.
+type fieldDiff struct {
+	Field  string
+	Source string
+	Stored string
+}
+
+// Outcome of reading one V2 service back from the K/V store.
+type verifyResult struct {
+	Service string
+	Fields  int
+	Diffs   []fieldDiff
+	Err     error
+}
+
+func (r verifyResult) passed() bool {
+	return r.Err == nil && len(r.Diffs) == 0
+}
+
+// Read every V2 service back from the K/V store after a seed, compare it with its configuration
+// files and print a pass/fail report. It fails when a single service does not match.
+func verifyConfig(profile string, coreConfig pkg.CoreConfig, s store.ConfigStore) error {
+	services, err := readV2ConfigFromPath(profile, coreConfig)
+	if err != nil {
+		return err
+	}
+	return verifyServices(services, s)
+}
+
+// Read services back from the K/V store and print their report, as verifyConfig does.
+// Services without a registered type are skipped.
+func verifyServices(services []*serviceConfig, s store.ConfigStore) error {
+	if !printVerifyReport(os.Stdout, verifyV2Config(services, s)) {
+		return withExitCode(exitPartialWrite, errors.New("the V2 configuration read back from the K/V store does not match its files"))
+	}
+	return nil
+}
+
+// Decode the source and the stored keys of every registered V2 service into its type and compare them.
+func verifyV2Config(services []*serviceConfig, s store.ConfigStore) []verifyResult {
+	results := []verifyResult{}
+	for _, service := range services {
+		st, ok := types.Lookup(strings.TrimSuffix(service.Dir, "/"))
+		if !ok {
+			continue
+		}
+		result := verifyResult{Service: st.Name}
+
+		source := st.New()
+		stored := st.New()
+		if err := client.Decode(service.Props, source); err != nil {
+			result.Err = fmt.Errorf("decoding the source: %s", err)
+		} else if err := decodePrefix(s, strings.TrimSuffix(service.Prefix, "/"), stored); err != nil {
+			result.Err = fmt.Errorf("decoding the stored keys: %s", err)
+		} else {
+			result.Fields, result.Diffs = compareFields("", reflect.ValueOf(source).Elem(), reflect.ValueOf(stored).Elem())
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// Decode the keys under a prefix of the K/V store into target, as the services do with pkg/v2/client.
+// The keys are read in one request.
+func decodePrefix(s store.ConfigStore, prefix string, target interface{}) error {
+	values, err := s.List(prefix + "/")
+	if err != nil {
+		return err
+	}
+
+	props := pkg.ConfigProperties{}
+	for key, value := range values {
+		if !strings.HasSuffix(key, "/") {
+			props[strings.TrimPrefix(key, prefix+"/")] = string(value)
+		}
+	}
+	return client.Decode(props, target)
+}
+
+// Compare two decoded values field by field, returning the number of leaf fields and the ones which differ.
+func compareFields(path string, source, stored reflect.Value) (int, []fieldDiff) {
+	switch source.Kind() {
+	case reflect.Struct:
+		fields := 0
+		diffs := []fieldDiff{}
+		for i := 0; i < source.NumField(); i++ {
+			n, d := compareFields(joinField(path, source.Type().Field(i).Name), source.Field(i), stored.Field(i))
+			fields += n
+			diffs = append(diffs, d...)
+		}
+		return fields, diffs
+	case reflect.Map:
+		keys := map[string]reflect.Value{}
+		for _, k := range append(source.MapKeys(), stored.MapKeys()...) {
+			keys[fmt.Sprint(k.Interface())] = k
+		}
+		names := []string{}
+		for name := range keys {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		fields := 0
+		diffs := []fieldDiff{}
+		for _, name := range names {
+			a, b := source.MapIndex(keys[name]), stored.MapIndex(keys[name])
+			if !a.IsValid() || !b.IsValid() {
+				fields++
+				diffs = append(diffs, fieldDiff{Field: joinField(path, name), Source: describeValue(a), Stored: describeValue(b)})
+				continue
+			}
+			n, d := compareFields(joinField(path, name), a, b)
+			fields += n
+			diffs = append(diffs, d...)
+		}
+		return fields, diffs
+	}
+
+	if !reflect.DeepEqual(source.Interface(), stored.Interface()) {
+		return 1, []fieldDiff{{Field: path, Source: describeValue(source), Stored: describeValue(stored)}}
+	}
+	return 1, nil
+}
+
+func joinField(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func describeValue(v reflect.Value) string {
+	if !v.IsValid() {
+		return "missing"
+	}
+	return fmt.Sprintf("%#v", v.Interface())
+}
+
+// Print one line per service, the fields which differ and a summary. It returns whether every service passed.
+func printVerifyReport(w io.Writer, results []verifyResult) bool {
+	failed := 0
+	for _, r := range results {
+		switch {
+		case r.Err != nil:
+			fmt.Fprintf(w, "FAIL %s: %s\n", r.Service, r.Err)
+		case len(r.Diffs) != 0:
+			fmt.Fprintf(w, "FAIL %s: %d of %d fields differ\n", r.Service, len(r.Diffs), r.Fields)
+			for _, d := range r.Diffs {
+				key := r.Service + "/" + strings.Replace(d.Field, ".", "/", -1)
+				fmt.Fprintf(w, "    %s: source %s, stored %s\n", d.Field, secrets.Redact(key, d.Source), secrets.Redact(key, d.Stored))
+			}
+		default:
+			fmt.Fprintf(w, "PASS %s (%d fields)\n", r.Service, r.Fields)
+		}
+		if !r.passed() {
+			failed++
+		}
+	}
+
+	if failed != 0 {
+		fmt.Fprintf(w, "Verification failed: %d of %d services differ.\n", failed, len(results))
+		return false
+	}
+	fmt.Fprintf(w, "Verification passed: %d services.\n", len(results))
+	return true
+}
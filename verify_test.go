@@ -0,0 +1,78 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg"
+)
+
+// A memoryStore refusing to read keys one by one.
+type listingStore struct {
+	*memoryStore
+}
+
+func (s listingStore) Get(key string) ([]byte, bool, error) {
+	return nil, false, errors.New("read " + key + " on its own")
+}
+
+func TestVerifyV2Config(t *testing.T) {
+	coreConfig := pkg.CoreConfig{
+		ConfigPathV2:                 "./pkg/v2/toml",
+		GlobalPrefix:                 "config",
+		AcceptablePropertyExtensions: []string{".toml"},
+		TomlExtensions:               []string{".toml"},
+	}
+	services, err := readV2ConfigFromPath("", coreConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newMemoryStore(nil)
+	for _, service := range services {
+		if err := putV2ServiceConfig(service, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	if !printVerifyReport(&out, verifyV2Config(services, listingStore{s})) {
+		t.Fatalf("expected the seeded services to pass:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "PASS EdgeX_Core_Data") || !strings.Contains(out.String(), "PASS EdgeX_Core_Command") {
+		t.Errorf("unexpected report:\n%s", out.String())
+	}
+
+	s.data["config/EdgeX_Core_Data/Service/Port"] = "48083"
+	delete(s.data, "config/EdgeX_Core_Data/Clients/Metadata/Host")
+
+	out.Reset()
+	if printVerifyReport(&out, verifyV2Config(services, s)) {
+		t.Fatalf("expected EdgeX_Core_Data to fail:\n%s", out.String())
+	}
+	for _, line := range []string{
+		"FAIL EdgeX_Core_Data: 2 of",
+		"    Clients.Metadata.Host: source \"localhost\", stored \"\"",
+		"    Service.Port: source 48082, stored 48083",
+		"PASS EdgeX_Core_Command",
+		"Verification failed: 1 of 2 services differ.",
+	} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("report is missing %q:\n%s", line, out.String())
+		}
+	}
+}
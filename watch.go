@@ -0,0 +1,188 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg"
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg/store"
+	"github.com/fsnotify/fsnotify"
+)
+
+// Sync the K/V store with the config files, then watch ConfigPath and ConfigPathV2 until ctx is
+// done. The services whose directories change are synced again once no change happened for
+// debounce. Every sync saves a snapshot first when enabled and verifies the services it wrote.
+// A file which cannot be read, e.g. while an editor saves it, or a sync which fails is logged and
+// retried at the next change.
+func watchConfig(ctx context.Context, profile string, coreConfig pkg.CoreConfig, s store.ConfigStore, debounce time.Duration) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	for _, root := range []string{coreConfig.ConfigPath, coreConfig.ConfigPathV2} {
+		if err := watchDirs(watcher, root); err != nil {
+			return withExitCode(exitConfigLoad, err)
+		}
+	}
+
+	err = saveSnapshotIfEnabled(coreConfig, s)
+	if err == nil {
+		err = syncConfig(profile, coreConfig, s)
+	}
+	if err == nil {
+		err = verifyConfig(profile, coreConfig, s)
+	}
+	if err != nil {
+		fmt.Println("watch:", err.Error())
+	}
+	fmt.Println("watching", coreConfig.ConfigPath, "and", coreConfig.ConfigPathV2, "for changes")
+
+	pending := map[string]bool{}
+	var settled <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-watcher.Errors:
+			fmt.Println("watch:", err.Error())
+		case event := <-watcher.Events:
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			// Directories created later are watched as well.
+			if info, err := os.Stat(event.Name); err == nil && info.IsDir() && event.Op&fsnotify.Create != 0 {
+				if err := watchDirs(watcher, event.Name); err != nil {
+					fmt.Println("watch:", err.Error())
+				}
+			}
+			if prefix, ok := changedPrefix(coreConfig, event.Name); ok {
+				pending[prefix] = true
+				settled = time.After(debounce)
+			}
+		case <-settled:
+			prefixes := []string{}
+			for prefix := range pending {
+				prefixes = append(prefixes, prefix)
+			}
+			sort.Strings(prefixes)
+			pending, settled = map[string]bool{}, nil
+
+			fmt.Println("config files changed under", strings.Join(prefixes, ", "))
+			if err := syncPrefixes(prefixes, profile, coreConfig, s); err != nil {
+				fmt.Println("watch:", err.Error())
+			}
+		}
+	}
+}
+
+// Watch a directory and every directory below it, fsnotify not being recursive.
+func watchDirs(watcher *fsnotify.Watcher, root string) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return err
+		}
+		return watcher.Add(path)
+	})
+}
+
+// The K/V prefix of the service directory a changed path belongs to. A path with an extension is
+// taken for a file, even once removed, and belongs to the service of its directory; any other
+// path is taken for a service directory.
+func changedPrefix(coreConfig pkg.CoreConfig, path string) (string, bool) {
+	for _, root := range []string{coreConfig.ConfigPath, coreConfig.ConfigPathV2} {
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+			continue
+		}
+
+		dir := rel
+		if info, err := os.Stat(path); (err == nil && !info.IsDir()) || (err != nil && filepath.Ext(path) != "") {
+			dir = filepath.Dir(rel)
+		}
+		if dir == "." {
+			return coreConfig.GlobalPrefix + "/", true
+		}
+		return coreConfig.GlobalPrefix + "/" + filepath.ToSlash(dir) + "/", true
+	}
+	return "", false
+}
+
+// Sync the keys under the given prefixes only, leaving the other services alone. Every config
+// file is read, the references of a service possibly pointing to another one, but only the keys
+// which differ under the prefixes are written and logged. A snapshot is saved before writing
+// when enabled, and the services written are verified.
+func syncPrefixes(prefixes []string, profile string, coreConfig pkg.CoreConfig, s store.ConfigStore) error {
+	under := func(key string) bool {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+
+	services, err := readServices(profile, coreConfig)
+	if err != nil {
+		return err
+	}
+	changed := []*serviceConfig{}
+	for _, service := range services {
+		if under(service.Prefix) {
+			changed = append(changed, service)
+		}
+	}
+	if err := writeSecrets(changed, coreConfig); err != nil {
+		return withExitCode(exitPartialWrite, err)
+	}
+
+	desired := flattenServices(changed)
+	all, err := storedConfig(coreConfig, s)
+	if err != nil {
+		return err
+	}
+	stored := pkg.ConfigProperties{}
+	for k, v := range all {
+		if under(k) {
+			stored[k] = v
+		}
+	}
+
+	plan := []planEntry{}
+	for _, e := range buildPlan(desired, stored, isPruning(coreConfig)) {
+		if e.Action != planKeep {
+			printPlanEntry(os.Stdout, e)
+			plan = append(plan, e)
+		}
+	}
+	if len(plan) == 0 {
+		return nil
+	}
+
+	if err := saveSnapshotIfEnabled(coreConfig, s); err != nil {
+		return err
+	}
+	if err := applyChanges(plan, coreConfig, stored, s); err != nil {
+		return withExitCode(exitPartialWrite, err)
+	}
+	return verifyServices(changed, s)
+}
@@ -0,0 +1,227 @@
+/*******************************************************************************
+ * Copyright 2018 Dell Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg"
+	"github.com/edgexfoundry/core-config-seed-go/internal/pkg/store"
+)
+
+// A memoryStore safe to read while a watch writes it.
+type lockedStore struct {
+	sync.Mutex
+	*memoryStore
+}
+
+func (s *lockedStore) Put(key string, value []byte) error {
+	s.Lock()
+	defer s.Unlock()
+	return s.memoryStore.Put(key, value)
+}
+
+func (s *lockedStore) Get(key string) ([]byte, bool, error) {
+	s.Lock()
+	defer s.Unlock()
+	return s.memoryStore.Get(key)
+}
+
+func (s *lockedStore) Keys(prefix string) ([]string, error) {
+	s.Lock()
+	defer s.Unlock()
+	return s.memoryStore.Keys(prefix)
+}
+
+func (s *lockedStore) List(prefix string) (map[string][]byte, error) {
+	s.Lock()
+	defer s.Unlock()
+	return s.memoryStore.List(prefix)
+}
+
+func (s *lockedStore) Delete(key string) error {
+	s.Lock()
+	defer s.Unlock()
+	return s.memoryStore.Delete(key)
+}
+
+func (s *lockedStore) DeleteTree(prefix string) error {
+	s.Lock()
+	defer s.Unlock()
+	return s.memoryStore.DeleteTree(prefix)
+}
+
+func (s *lockedStore) Txn(ops []store.TxnOp) error {
+	s.Lock()
+	defer s.Unlock()
+	return s.memoryStore.Txn(ops)
+}
+
+func TestWatchConfig(t *testing.T) {
+	root, err := ioutil.TempDir("", "watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	write := func(path string, contents string) {
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("v2/Svc/"+configDefault, "[Service]\nPort = 48080\n")
+	write("v1/Other/application.properties", "Key=file\n")
+
+	// The V1 reader takes ConfigPath relative to the working directory.
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rel, err := filepath.Rel(wd, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	coreConfig := pkg.CoreConfig{ConfigPath: filepath.Join(rel, "v1"), ConfigPathV2: filepath.Join(rel, "v2"),
+		GlobalPrefix: "config", AcceptablePropertyExtensions: []string{".properties", ".toml"}}
+	s := &lockedStore{memoryStore: newMemoryStore(nil)}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- watchConfig(ctx, "", coreConfig, s, 50*time.Millisecond) }()
+
+	waitFor := func(key string, value string) {
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if v, _, _ := s.Get(key); string(v) == value {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("the store never got %s = %s", key, value)
+	}
+	waitFor("config/Svc/Service/Port", "48080")
+	waitFor("config/Other/Key", "file")
+
+	// Only the service whose files changed is synced again.
+	s.Put("config/Other/Key", []byte("edited"))
+	write("v2/Svc/"+configDefault, "[Service]\nPort = 48090\n")
+	waitFor("config/Svc/Service/Port", "48090")
+	if v, _, _ := s.Get("config/Other/Key"); string(v) != "edited" {
+		t.Errorf("the unchanged service was synced, Key = %s", v)
+	}
+
+	// A service directory created while watching is watched as well.
+	write("v2/New/"+configDefault, "[Service]\nPort = 48100\n")
+	waitFor("config/New/Service/Port", "48100")
+	write("v2/New/"+configDefault, "[Service]\nPort = 48101\n")
+	waitFor("config/New/Service/Port", "48101")
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+}
+
+// A store losing the writes of one key.
+type lossyStore struct {
+	*memoryStore
+	lost string
+}
+
+func (s *lossyStore) Put(key string, value []byte) error {
+	if key == s.lost {
+		return nil
+	}
+	return s.memoryStore.Put(key, value)
+}
+
+// A resync saves a snapshot before it writes and verifies the services written.
+func TestSyncPrefixesSnapshotsAndVerifies(t *testing.T) {
+	root, err := ioutil.TempDir("", "watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	writeCoreDataConfig(t, filepath.Join(root, "v2"), "", "")
+	if err := os.MkdirAll(filepath.Join(root, "v1"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	coreConfig := pkg.CoreConfig{ConfigPath: filepath.Join(root, "v1"), ConfigPathV2: filepath.Join(root, "v2"),
+		GlobalPrefix: "config", SnapshotPrefix: "snapshots"}
+	s := &lossyStore{memoryStore: newMemoryStore(nil)}
+	prefixes := []string{"config/EdgeX_Core_Data/"}
+	snapshots := func() int {
+		keys, _ := s.Keys("snapshots/")
+		return len(keys)
+	}
+
+	if err := syncPrefixes(prefixes, "", coreConfig, s); err != nil {
+		t.Fatal(err)
+	}
+	if n := snapshots(); n != 1 {
+		t.Errorf("expected a snapshot before the first write, got %d", n)
+	}
+	// Nothing to write, nothing to keep.
+	if err := syncPrefixes(prefixes, "", coreConfig, s); err != nil {
+		t.Fatal(err)
+	}
+	if n := snapshots(); n != 1 {
+		t.Errorf("expected no snapshot without a write, got %d", n)
+	}
+
+	s.lost = "config/EdgeX_Core_Data/Service/Port"
+	delete(s.data, s.lost)
+	if err := syncPrefixes(prefixes, "", coreConfig, s); exitCode(err) != exitPartialWrite {
+		t.Errorf("expected the verification to fail, got %v", err)
+	}
+	if n := snapshots(); n != 2 {
+		t.Errorf("expected a second snapshot, got %d", n)
+	}
+}
+
+func TestChangedPrefix(t *testing.T) {
+	root, err := ioutil.TempDir("", "watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	if err := os.MkdirAll(filepath.Join(root, "v2", "Svc"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	coreConfig := pkg.CoreConfig{ConfigPath: filepath.Join(root, "v1"), ConfigPathV2: filepath.Join(root, "v2"), GlobalPrefix: "config"}
+
+	tests := map[string]string{
+		"v2/Svc":                       "config/Svc/",
+		"v2/Svc/configuration.toml":    "config/Svc/",
+		"v1/edgex-core-data;docker":    "config/edgex-core-data;docker/",
+		"v1/device;go/app.properties":  "config/device;go/",
+		"v1/root.properties":           "config/",
+		"elsewhere/configuration.toml": "",
+	}
+	for path, expected := range tests {
+		prefix, ok := changedPrefix(coreConfig, filepath.Join(root, path))
+		if prefix != expected || ok != (expected != "") {
+			t.Errorf("%s: expected %q, got %q", path, expected, prefix)
+		}
+	}
+}